@@ -0,0 +1,209 @@
+package cli
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"workflo/githubactions"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/google/go-github/v41/github"
+)
+
+// Secret naming strategies
+const (
+	// namingWorkflowPrefix prefixes every name with the workflow name
+	namingWorkflowPrefix = "workflow"
+	// namingShared uses the bare field names so other workflows can reuse them
+	namingShared = "shared"
+	// namingEnvironmentSuffix stores repository secrets suffixed with the
+	// environment name, e.g. AWS_ACCESS_KEY_ID_STAGING
+	namingEnvironmentSuffix = "environment"
+)
+
+// cloudAccount is one set of provider credentials and the environment its
+// deploy job runs in. The wizard collects several when, for example, staging
+// and production live in different AWS accounts.
+type cloudAccount struct {
+	Values      map[string]string
+	Environment environmentConfig
+}
+
+// allAccounts returns the finished accounts followed by the one being collected
+func (m model) allAccounts() []cloudAccount {
+	current := cloudAccount{Values: m.credentialValues, Environment: m.environment}
+	return append(append([]cloudAccount(nil), m.accounts...), current)
+}
+
+// cloudConfig bundles the answers of the first account, which the build and
+// Docker jobs use
+func (m model) cloudConfig() githubactions.CloudConfig {
+	return m.cloudConfigFor(m.allAccounts()[0])
+}
+
+// cloudConfigFor bundles an account's answers with the naming strategy
+func (m model) cloudConfigFor(account cloudAccount) githubactions.CloudConfig {
+	cfg := githubactions.CloudConfig{
+		OIDC:      m.oidc,
+		Values:    account.Values,
+		Variables: m.variables,
+	}
+	switch m.secretNaming {
+	case namingShared:
+	case namingEnvironmentSuffix:
+		cfg.Suffix = githubactions.SanitizeSecretPrefix(account.Environment.Name)
+	default:
+		cfg.Prefix = m.workflowNameUpper
+	}
+	return cfg
+}
+
+// secretEnvironment returns the environment an account's secrets and
+// variables are stored in, or "" for the repository. Suffixed names are
+// repository secrets, so every job can read them.
+func (m model) secretEnvironment(account cloudAccount) string {
+	if m.secretNaming == namingEnvironmentSuffix {
+		return ""
+	}
+	return account.Environment.Name
+}
+
+// dockerEnvironment returns the environment the Docker job runs in. A
+// registry login through the cloud provider reads the first account's
+// secrets, so the job joins that account's environment to keep them scoped.
+func (m model) dockerEnvironment() string {
+	if m.dockerRegistry == nil || m.dockerRegistry.Provider == "" {
+		return ""
+	}
+	return m.secretEnvironment(m.allAccounts()[0])
+}
+
+// secretStoreFor returns where an account's secrets are uploaded
+func (m model) secretStoreFor(ctx context.Context, client *github.Client, account cloudAccount) (secretStore, error) {
+	if env := m.secretEnvironment(account); env != "" {
+		return newEnvSecretStore(ctx, client, m.githubUsername, m.githubRepoName, env)
+	}
+	return repoSecretStore{client: client, owner: m.githubUsername, repo: m.githubRepoName}, nil
+}
+
+var nonJobIDChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// deployJobID names the deploy job of an account. A single account keeps
+// the plain "deploy" job.
+func (m model) deployJobID(account cloudAccount) string {
+	if len(m.accounts) == 0 {
+		return "deploy"
+	}
+	return "deploy-" + strings.Trim(nonJobIDChars.ReplaceAllString(strings.ToLower(account.Environment.Name), "-"), "-")
+}
+
+// environmentTaken reports whether a finished account already deploys to env
+func (m model) environmentTaken(env string) bool {
+	for _, account := range m.accounts {
+		if strings.EqualFold(account.Environment.Name, env) {
+			return true
+		}
+	}
+	return false
+}
+
+// finishEnvironment offers another account when each deploy job can get its
+// own environment, then asks how to name the secrets
+func (m model) finishEnvironment() (tea.Model, tea.Cmd) {
+	if m.deployTarget != nil && m.environment.Name != "" {
+		m.state = stateAnotherAccount
+		return m, nil
+	}
+	return m.startSecretNaming()
+}
+
+// handleAnotherAccountState starts collecting a further account's
+// credentials and deploy settings, or moves on
+func (m model) handleAnotherAccountState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			selectedOption := m.anotherAccountOption.SelectedItem()
+			if selectedOption == nil {
+				return m, cmd
+			}
+			if selectedOption.FilterValue() != "Yes" {
+				return m.startSecretNaming()
+			}
+			m.accounts = append(m.accounts, cloudAccount{Values: m.credentialValues, Environment: m.environment})
+			m.credentialValues = make(map[string]string)
+			m.environment = environmentConfig{}
+			m.credentialFields = append(m.cloudProvider.Fields(m.oidc), m.deployTarget.Fields...)
+			m.credentialIndex = 0
+			m.credentialError = ""
+			m.credentialInput = newCredentialInput(m.credentialFields[0])
+			m.state = stateCloudCredentials
+			return m, textinput.Blink
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		}
+	}
+	return m, cmd
+}
+
+// startSecretNaming asks how secrets are named. Suffixes are only offered
+// when every account has an environment.
+func (m model) startSecretNaming() (tea.Model, tea.Cmd) {
+	if m.cloudProvider == nil {
+		m.state = stateConfigureSecretsOption
+		return m, nil
+	}
+
+	m.namingChoices = nil
+	var items []list.Item
+	exampleKey := "API_TOKEN"
+	if fields := m.cloudProvider.Fields(m.oidc); len(fields) > 0 {
+		exampleKey = fields[0].Key
+	}
+	example := func(naming string) string {
+		n := m
+		n.secretNaming = naming
+		return n.cloudConfig().SecretName(exampleKey)
+	}
+	if m.workflowNameUpper != "" {
+		m.namingChoices = append(m.namingChoices, namingWorkflowPrefix)
+		items = append(items, item("Prefix with the workflow name: "+example(namingWorkflowPrefix)))
+	}
+	m.namingChoices = append(m.namingChoices, namingShared)
+	items = append(items, item("Shared across workflows: "+example(namingShared)))
+	withEnvironments := true
+	for _, account := range m.allAccounts() {
+		withEnvironments = withEnvironments && account.Environment.Name != ""
+	}
+	if withEnvironments {
+		m.namingChoices = append(m.namingChoices, namingEnvironmentSuffix)
+		items = append(items, item("Environment suffix, as repository secrets: "+example(namingEnvironmentSuffix)))
+	}
+
+	m.secretNamingOption = list.New(items, list.NewDefaultDelegate(), 70, 9)
+	m.secretNamingOption.Title = "How should the secrets be named?"
+	m.secretNamingOption.SetShowStatusBar(false)
+	m.secretNamingOption.SetShowHelp(false)
+	m.state = stateSecretNaming
+	return m, nil
+}
+
+// handleSecretNamingState processes the naming strategy
+func (m model) handleSecretNamingState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if i := m.secretNamingOption.Index(); i >= 0 && i < len(m.namingChoices) {
+				m.secretNaming = m.namingChoices[i]
+				m.state = stateConfigureSecretsOption
+			}
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		}
+	}
+	return m, cmd
+}
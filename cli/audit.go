@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"workflo/githubactions"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// Audit finding kinds, in report order
+const (
+	auditMissing  = "missing"
+	auditUnused   = "unused"
+	auditShadowed = "shadowed"
+)
+
+// auditFinding is one problem reported by `secrets audit`
+type auditFinding struct {
+	Kind  string
+	Name  string
+	Where string
+}
+
+// workflowSecretRef is a secret reference together with its workflow file
+type workflowSecretRef struct {
+	githubactions.SecretRef
+	File string
+}
+
+// secretInventory lists the secrets a repository's workflows can read
+type secretInventory struct {
+	org  map[string]bool
+	repo map[string]bool
+	envs map[string]map[string]bool // keyed by lower-cased environment name
+	// envNames maps the keys of envs back to the environment names
+	envNames map[string]string
+}
+
+// runSecretsAudit compares the secrets the workflows reference with the
+// secrets that exist, returning an error when anything is missing, unused or
+// shadowed so CI can fail on it
+func runSecretsAudit(args []string) error {
+	var opts secretsFlags
+	fs := newSecretsFlagSet("audit", &opts)
+	dir := fs.String("dir", githubactions.WorkflowsDir, "directory containing the workflow files")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+
+	refs, files, err := readWorkflowSecretRefs(*dir)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, owner, repo, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	inventory, err := fetchSecretInventory(ctx, client, owner, repo, refs)
+	if err != nil {
+		return err
+	}
+
+	findings := auditSecrets(refs, inventory)
+	if len(findings) == 0 {
+		fmt.Fprintf(stdout, "No problems found in %d secret reference(s) across %d workflow file(s).\n", len(refs), files)
+		return nil
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tSECRET\tWHERE")
+	for _, finding := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", finding.Kind, finding.Name, finding.Where)
+	}
+	w.Flush()
+	return fmt.Errorf("%d problem(s) found in %s/%s", len(findings), owner, repo)
+}
+
+// readWorkflowSecretRefs collects the secret references of every workflow
+// file in dir and returns how many files were read
+func readWorkflowSecretRefs(dir string) ([]workflowSecretRef, int, error) {
+	files, err := githubactions.WorkflowFiles(dir)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error reading workflow directory: %v", err)
+	}
+	var refs []workflowSecretRef
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error reading %s: %v", file, err)
+		}
+		found, err := githubactions.FindSecretRefs(data)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error reading %s: %v", file, err)
+		}
+		for _, ref := range found {
+			refs = append(refs, workflowSecretRef{SecretRef: ref, File: file})
+		}
+	}
+	return refs, len(files), nil
+}
+
+// fetchSecretInventory lists the repository's secrets, the secrets of its
+// environments and of any environment the workflows name, and the
+// organization secrets shared with the repository
+func fetchSecretInventory(ctx context.Context, client *github.Client, owner, repo string, refs []workflowSecretRef) (secretInventory, error) {
+	inventory := secretInventory{envs: make(map[string]map[string]bool), envNames: make(map[string]string)}
+
+	var err error
+	if inventory.repo, err = (repoSecretStore{client: client, owner: owner, repo: repo}).Names(ctx); err != nil {
+		return inventory, fmt.Errorf("error listing secrets: %v", err)
+	}
+	// Organization secrets only matter for references, so a token that
+	// cannot list them is not fatal
+	if inventory.org, err = listRepoOrgSecrets(ctx, client, owner, repo); err != nil {
+		fmt.Fprintf(stderr, "Warning: could not list organization secrets: %s\n", shortError(err))
+	}
+
+	envs := make(map[string]string)
+	environments, _, err := client.Repositories.ListEnvironments(ctx, owner, repo)
+	if err != nil {
+		return inventory, fmt.Errorf("error listing environments: %v", err)
+	}
+	for _, env := range environments.Environments {
+		envs[strings.ToLower(env.GetName())] = env.GetName()
+	}
+	for _, ref := range refs {
+		if ref.Environment != "" {
+			envs[strings.ToLower(ref.Environment)] = ref.Environment
+		}
+	}
+	if len(envs) == 0 {
+		return inventory, nil
+	}
+
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return inventory, fmt.Errorf("error getting repository: %v", err)
+	}
+	for key, env := range envs {
+		store := envSecretStore{client: client, repoID: int(repository.GetID()), repo: owner + "/" + repo, env: env}
+		names, err := store.Names(ctx)
+		// An environment named by a workflow may not exist yet
+		var apiErr *github.ErrorResponse
+		if errors.As(err, &apiErr) && apiErr.Response.StatusCode == http.StatusNotFound {
+			names, err = map[string]bool{}, nil
+		}
+		if err != nil {
+			return inventory, fmt.Errorf("error listing secrets in %s: %v", store, err)
+		}
+		inventory.envs[key] = names
+		inventory.envNames[key] = env
+	}
+	return inventory, nil
+}
+
+// listRepoOrgSecrets lists the organization secrets shared with a
+// repository, which the go-github version in use does not cover
+func listRepoOrgSecrets(ctx context.Context, client *github.Client, owner, repo string) (map[string]bool, error) {
+	return listSecretNames(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		u := fmt.Sprintf("repos/%s/%s/actions/organization-secrets?per_page=%d&page=%d", owner, repo, opts.PerPage, opts.Page)
+		req, err := client.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		secrets := new(github.Secrets)
+		resp, err := client.Do(ctx, req, secrets)
+		return secrets, resp, err
+	})
+}
+
+// auditSecrets reports references to secrets that do not exist, secrets no
+// workflow references, and environment or repository secrets that hide a
+// secret of the same name at a broader level
+func auditSecrets(refs []workflowSecretRef, inventory secretInventory) []auditFinding {
+	var findings []auditFinding
+	seen := make(map[auditFinding]bool)
+	add := func(finding auditFinding) {
+		if !seen[finding] {
+			seen[finding] = true
+			findings = append(findings, finding)
+		}
+	}
+
+	used := make(map[string]bool)
+	usedInEnv := make(map[string]map[string]bool)
+	for _, ref := range refs {
+		env := strings.ToLower(ref.Environment)
+		used[ref.Name] = true
+		if usedInEnv[env] == nil {
+			usedInEnv[env] = make(map[string]bool)
+		}
+		usedInEnv[env][ref.Name] = true
+
+		if inventory.envs[env][ref.Name] || inventory.repo[ref.Name] || inventory.org[ref.Name] {
+			continue
+		}
+		add(auditFinding{Kind: auditMissing, Name: ref.Name, Where: refLocation(ref)})
+	}
+
+	for name := range inventory.repo {
+		if !used[name] {
+			add(auditFinding{Kind: auditUnused, Name: name, Where: "repository"})
+		}
+		if inventory.org[name] {
+			add(auditFinding{Kind: auditShadowed, Name: name, Where: "repository secret overrides the organization secret"})
+		}
+	}
+	for env, names := range inventory.envs {
+		label := "environment " + inventory.envNames[env]
+		for name := range names {
+			if !usedInEnv[env][name] {
+				add(auditFinding{Kind: auditUnused, Name: name, Where: label})
+			}
+			if inventory.repo[name] {
+				add(auditFinding{Kind: auditShadowed, Name: name, Where: label + " overrides the repository secret"})
+			} else if inventory.org[name] {
+				add(auditFinding{Kind: auditShadowed, Name: name, Where: label + " overrides the organization secret"})
+			}
+		}
+	}
+
+	order := map[string]int{auditMissing: 0, auditUnused: 1, auditShadowed: 2}
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Kind != b.Kind {
+			return order[a.Kind] < order[b.Kind]
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Where < b.Where
+	})
+	return findings
+}
+
+// refLocation describes where a reference was found
+func refLocation(ref workflowSecretRef) string {
+	switch {
+	case ref.Job == "":
+		return ref.File + " (workflow env)"
+	case ref.Environment != "":
+		return fmt.Sprintf("%s (job %s, environment %s)", ref.File, ref.Job, ref.Environment)
+	default:
+		return fmt.Sprintf("%s (job %s)", ref.File, ref.Job)
+	}
+}
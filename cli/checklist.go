@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// checklist is a list of items that can each be toggled on or off
+type checklist struct {
+	title   string
+	items   []string
+	checked []bool
+	cursor  int
+}
+
+func newChecklist(title string, items []string, checked bool) checklist {
+	c := checklist{
+		title:   title,
+		items:   items,
+		checked: make([]bool, len(items)),
+	}
+	for i := range c.checked {
+		c.checked[i] = checked
+	}
+	return c
+}
+
+// Update moves the cursor and toggles items
+func (c checklist) Update(msg tea.Msg) checklist {
+	// Copy so toggling never mutates a previous model's slice
+	c.checked = append([]bool(nil), c.checked...)
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if c.cursor > 0 {
+				c.cursor--
+			}
+		case "down", "j":
+			if c.cursor < len(c.items)-1 {
+				c.cursor++
+			}
+		case " ", "x":
+			if len(c.items) > 0 {
+				c.checked[c.cursor] = !c.checked[c.cursor]
+			}
+		case "a":
+			for i := range c.checked {
+				c.checked[i] = true
+			}
+		case "n":
+			for i := range c.checked {
+				c.checked[i] = false
+			}
+		}
+	}
+	return c
+}
+
+// Selected returns the indexes of the checked items
+func (c checklist) Selected() []int {
+	var selected []int
+	for i, checked := range c.checked {
+		if checked {
+			selected = append(selected, i)
+		}
+	}
+	return selected
+}
+
+// View renders the checklist with its title and key help
+func (c checklist) View() string {
+	var b strings.Builder
+	b.WriteString(c.title + "\n\n")
+	for i, label := range c.items {
+		cursor := " "
+		if i == c.cursor {
+			cursor = ">"
+		}
+		check := " "
+		if c.checked[i] {
+			check = "x"
+		}
+		fmt.Fprintf(&b, "%s [%s] %s\n", cursor, check, label)
+	}
+	b.WriteString("\n(Space to toggle, a/n to select all/none, Enter to confirm)\n")
+	return b.String()
+}
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// apiURLEnv overrides the exact GitHub API base URL, e.g. to point
+	// workflo at a local stand-in server
+	apiURLEnv = "WORKFLO_API_URL"
+	// githubURLEnv selects a GitHub Enterprise Server instance
+	githubURLEnv = "WORKFLO_GITHUB_URL"
+)
+
+// githubServer selects the GitHub instance workflo talks to. Both fields
+// empty means github.com.
+type githubServer struct {
+	// URL is a GitHub Enterprise Server URL such as https://ghe.example.com.
+	// The API is expected under /api/v3, as on a real instance.
+	URL string
+	// APIURL is an exact API base URL, used as is. It wins over URL.
+	APIURL string
+}
+
+// registerFlags adds --github-url and --api-url to fs
+func (s *githubServer) registerFlags(fs *flag.FlagSet) {
+	fs.StringVar(&s.URL, "github-url", "", "GitHub Enterprise Server URL (defaults to $"+githubURLEnv+", the config file or github.com)")
+	fs.StringVar(&s.APIURL, "api-url", "", "exact GitHub API base URL, e.g. of a mock server (defaults to $"+apiURLEnv+")")
+}
+
+// resolve fills empty fields from the environment, then the config file
+func (s githubServer) resolve() githubServer {
+	if s.APIURL == "" && s.URL == "" {
+		s.APIURL = os.Getenv(apiURLEnv)
+	}
+	if s.APIURL == "" && s.URL == "" {
+		s.URL = os.Getenv(githubURLEnv)
+	}
+	if s.APIURL == "" && s.URL == "" {
+		if cfg, err := loadConfig(); err == nil {
+			s.URL = cfg.GitHubURL
+		}
+	}
+	return s
+}
+
+// Host returns the host name of the selected instance, github.com by default
+func (s githubServer) Host() string {
+	s = s.resolve()
+	raw := s.APIURL
+	if raw == "" {
+		raw = s.URL
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" || u.Hostname() == "api.github.com" {
+		return defaultGitHubHost
+	}
+	return u.Hostname()
+}
+
+// gitHubSSHHosts are remote hosts that are github.com under another name
+var gitHubSSHHosts = map[string]bool{defaultGitHubHost: true, "ssh.github.com": true, "www.github.com": true}
+
+// serverForRemote returns the server for a git remote's host. A server set
+// by flag, environment or config file wins; otherwise a remote on another
+// host selects that GitHub Enterprise Server. Hosts without a dot, such as
+// the SSH alias github-work, are not real hosts, so they keep github.com. The
+// warning explains when the remote does not match the server used.
+func serverForRemote(configured githubServer, host string) (githubServer, string) {
+	resolved := configured.resolve()
+	switch {
+	case resolved.APIURL != "":
+		// An exact API URL, e.g. of a mock server, is used whatever the remote
+		return configured, ""
+	case resolved.URL == "" && host != "" && !gitHubSSHHosts[host] && strings.Contains(host, "."):
+		return githubServer{URL: "https://" + host}, ""
+	}
+	selected := configured.Host()
+	if host == "" || host == selected || (selected == defaultGitHubHost && gitHubSSHHosts[host]) {
+		return configured, ""
+	}
+	return configured, fmt.Sprintf("the git remote is on %s but workflo uses %s; pass --github-url or set github_url in the config file if the repository is on a GitHub Enterprise Server", host, selected)
+}
+
+// newGitHubClient builds a GitHub API client for server. An empty token
+// gives an unauthenticated client.
+func newGitHubClient(ctx context.Context, token string, server githubServer) (*github.Client, error) {
+	var httpClient *http.Client
+	if token != "" {
+		ts := oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: token},
+		)
+		httpClient = oauth2.NewClient(ctx, ts)
+	}
+
+	server = server.resolve()
+	switch {
+	case server.APIURL != "":
+		apiURL := server.APIURL
+		if !strings.HasSuffix(apiURL, "/") {
+			apiURL += "/"
+		}
+		baseURL, err := url.Parse(apiURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API URL %q: %v", server.APIURL, err)
+		}
+		client := github.NewClient(httpClient)
+		client.BaseURL = baseURL
+		client.UploadURL = baseURL
+		return client, nil
+	case server.URL != "":
+		client, err := github.NewEnterpriseClient(server.URL, server.URL, httpClient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub URL %q: %v", server.URL, err)
+		}
+		return client, nil
+	default:
+		return github.NewClient(httpClient), nil
+	}
+}
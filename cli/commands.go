@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const usage = `Usage: workflo [command] [flags]
+
+Run without a command to start the interactive workflow wizard. The wizard
+and every command accept --github-url to use a GitHub Enterprise Server
+instance, and --api-url for an exact API base URL such as a mock server.
+Both can also come from $WORKFLO_GITHUB_URL / $WORKFLO_API_URL, or
+github_url in the config file ($WORKFLO_CONFIG or ~/.config/workflo/config.yml).
+
+Commands:
+  pin        Pin every action in .github/workflows to a full commit SHA
+  upgrade    Upgrade actions in .github/workflows to their latest major version
+  secrets    List, set, delete and sync repository secrets
+  help       Show this message
+`
+
+// Run executes a workflo subcommand given the command line arguments after
+// the program name. Secret values are redacted from the returned error.
+func Run(args []string) error {
+	err := run(args)
+	// -h prints the flag usage, which is not a failure
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return redactError(err)
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+
+	switch args[0] {
+	case "pin":
+		return runPin(args[1:])
+	case "upgrade":
+		return runUpgrade(args[1:])
+	case "secrets":
+		return runSecrets(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		if strings.HasPrefix(args[0], "-") {
+			return runWizard(args)
+		}
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runWizard starts the interactive wizard with flags selecting the GitHub
+// instance, e.g. `workflo --github-url https://ghe.example.com`
+func runWizard(args []string) error {
+	m := NewModel()
+	fs := flag.NewFlagSet("workflo", flag.ContinueOnError)
+	m.githubServer.registerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if _, err := tea.NewProgram(m).Run(); err != nil {
+		return fmt.Errorf("error running program: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// configEnv overrides the path of the config file
+const configEnv = "WORKFLO_CONFIG"
+
+// config is workflo's user configuration, read from config.yml in the user
+// config directory (e.g. ~/.config/workflo/config.yml)
+type config struct {
+	// GitHubURL selects a GitHub Enterprise Server instance
+	GitHubURL string `yaml:"github_url"`
+	// MaxSecretAge is how long a secret may go without rotation before
+	// `secrets list` flags it, e.g. 90d
+	MaxSecretAge string `yaml:"max_secret_age"`
+}
+
+// configPath returns the location of the config file
+func configPath() (string, error) {
+	if path := os.Getenv(configEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "workflo", "config.yml"), nil
+}
+
+// loadConfig reads the config file. A missing file gives an empty config.
+func loadConfig() (config, error) {
+	var cfg config
+	path, err := configPath()
+	if err != nil {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// dotenvEntry is a single KEY=VALUE assignment read from a .env file
+type dotenvEntry struct {
+	Key   string
+	Value string
+	Line  int // 1-based line the assignment starts on
+}
+
+// readDotenv parses the dotenv file at path
+func readDotenv(path string) ([]dotenvEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	entries, err := parseDotenv(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	return entries, nil
+}
+
+// parseDotenv parses dotenv content. It supports `export` prefixes, comments,
+// single quoted literal values, and double quoted values with escapes; both
+// quote styles may span several lines. A key assigned twice keeps its first
+// position and its last value.
+func parseDotenv(content string) ([]dotenvEntry, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(content, "\n")
+
+	var entries []dotenvEntry
+	index := make(map[string]int)
+	for i := 0; i < len(lines); i++ {
+		start := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, rest, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", start)
+		}
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value string
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			quote := rest[0]
+			body := rest[1:]
+			// Keep consuming lines until the closing quote
+			for {
+				if end := closingQuote(body, quote); end >= 0 {
+					if tail := strings.TrimSpace(body[end+1:]); tail != "" && !strings.HasPrefix(tail, "#") {
+						return nil, fmt.Errorf("line %d: unexpected text after closing quote", i+1)
+					}
+					body = body[:end]
+					break
+				}
+				i++
+				if i >= len(lines) {
+					return nil, fmt.Errorf("line %d: unterminated %c quote", start, quote)
+				}
+				body += "\n" + lines[i]
+			}
+			if quote == '"' {
+				body = unescapeDotenv(body)
+			}
+			value = body
+		} else {
+			// Unquoted values end at an inline comment, and one right after
+			// the = leaves the value empty
+			if strings.HasPrefix(rest, "#") {
+				rest = ""
+			} else if hash := strings.Index(rest, " #"); hash >= 0 {
+				rest = rest[:hash]
+			}
+			value = strings.TrimSpace(rest)
+		}
+
+		if pos, ok := index[key]; ok {
+			entries[pos].Value = value
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, dotenvEntry{Key: key, Value: value, Line: start})
+	}
+	return entries, nil
+}
+
+// closingQuote returns the index of the quote ending s, skipping escaped
+// double quotes, or -1 if s does not contain it
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDotenv expands the escapes allowed in double quoted values
+func unescapeDotenv(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// Environment list entries that are not environment names
+const (
+	noEnvironment    = "No environment (repository secrets)"
+	otherEnvironment = "Other (enter a name)"
+)
+
+// maxWaitTimer is the longest wait timer GitHub accepts, in minutes (30 days)
+const maxWaitTimer = 43200
+
+// environmentConfig is a GitHub Environment and its protection rules
+type environmentConfig struct {
+	Name string
+	// Reviewers are user logins or org/team slugs who must approve a deployment
+	Reviewers []string
+	// WaitTimer delays jobs referencing the environment, in minutes
+	WaitTimer int
+	// ProtectedBranches limits deployments to protected branches
+	ProtectedBranches bool
+}
+
+// parseReviewers splits a comma separated list of logins and org/team slugs
+func parseReviewers(value string) []string {
+	var reviewers []string
+	for _, reviewer := range strings.Split(value, ",") {
+		if reviewer = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(reviewer), "@")); reviewer != "" {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+	return reviewers
+}
+
+// configureEnvironment creates the environment, or updates it when it
+// already exists, applying its protection rules
+func configureEnvironment(ctx context.Context, client *github.Client, owner, repo string, env environmentConfig) error {
+	reviewers, err := resolveReviewers(ctx, client, env.Reviewers)
+	if err != nil {
+		return err
+	}
+
+	opts := &github.CreateUpdateEnvironment{
+		WaitTimer: github.Int(env.WaitTimer),
+		Reviewers: reviewers,
+	}
+	if env.ProtectedBranches {
+		opts.DeploymentBranchPolicy = &github.BranchPolicy{
+			ProtectedBranches:    github.Bool(true),
+			CustomBranchPolicies: github.Bool(false),
+		}
+	}
+
+	if _, _, err := client.Repositories.CreateUpdateEnvironment(ctx, owner, repo, env.Name, opts); err != nil {
+		return fmt.Errorf("error configuring environment %s: %v", env.Name, err)
+	}
+	return nil
+}
+
+// resolveReviewers looks up the IDs GitHub needs for each reviewer. Entries
+// containing a slash are org/team slugs, anything else is a user login.
+func resolveReviewers(ctx context.Context, client *github.Client, names []string) ([]*github.EnvReviewers, error) {
+	var reviewers []*github.EnvReviewers
+	for _, name := range names {
+		if org, slug, ok := strings.Cut(name, "/"); ok {
+			team, _, err := client.Teams.GetTeamBySlug(ctx, org, slug)
+			if err != nil {
+				return nil, fmt.Errorf("error looking up team %s: %v", name, err)
+			}
+			reviewers = append(reviewers, &github.EnvReviewers{Type: github.String("Team"), ID: team.ID})
+			continue
+		}
+		user, _, err := client.Users.Get(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up user %s: %v", name, err)
+		}
+		reviewers = append(reviewers, &github.EnvReviewers{Type: github.String("User"), ID: user.ID})
+	}
+	return reviewers, nil
+}
+
+// configureEnvironmentSecrets encrypts the secrets with the environment's
+// public key, uploads them to the environment and reports the outcome of each
+func configureEnvironmentSecrets(ctx context.Context, client *github.Client, owner, repo, env string, secrets map[string]string) (secretReport, error) {
+	store, err := newEnvSecretStore(ctx, client, owner, repo, env)
+	if err != nil {
+		return nil, err
+	}
+	return uploadSecrets(ctx, store, secrets)
+}
+
+// newEnvSecretStore looks up the repository ID the environment secret
+// endpoints address the repository by
+func newEnvSecretStore(ctx context.Context, client *github.Client, owner, repo, env string) (envSecretStore, error) {
+	repository, _, err := client.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return envSecretStore{}, fmt.Errorf("error getting repository: %v", err)
+	}
+	return envSecretStore{
+		client: client,
+		repoID: int(repository.GetID()),
+		repo:   owner + "/" + repo,
+		env:    env,
+	}, nil
+}
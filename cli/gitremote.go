@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultGitHubHost is the host of github.com repositories
+const defaultGitHubHost = "github.com"
+
+// gitRemote is a remote from .git/config with its URL parsed into the
+// GitHub host, owner and repository name
+type gitRemote struct {
+	Name  string
+	URL   string
+	Host  string
+	Owner string
+	Repo  string
+}
+
+func (r gitRemote) String() string {
+	return fmt.Sprintf("%s (%s/%s/%s)", r.Name, r.Host, r.Owner, r.Repo)
+}
+
+// scpLikePattern matches SSH remotes in the scp form, git@host:owner/repo.git
+var scpLikePattern = regexp.MustCompile(`^(?:[^@/]+@)?([^:/]+):(.+)$`)
+
+// parseRemoteURL extracts the host, owner and repository from the SSH
+// (git@host:owner/repo.git, ssh://git@host:22/owner/repo) and HTTPS
+// (https://host/owner/repo.git) remote URL forms
+func parseRemoteURL(remote string) (host, owner, repo string, ok bool) {
+	remote = strings.TrimSpace(remote)
+	var path string
+	if strings.Contains(remote, "://") {
+		u, err := url.Parse(remote)
+		if err != nil || u.Hostname() == "" {
+			return "", "", "", false
+		}
+		host, path = u.Hostname(), u.Path
+	} else if m := scpLikePattern.FindStringSubmatch(remote); m != nil {
+		host, path = m[1], m[2]
+	} else {
+		return "", "", "", false
+	}
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", false
+	}
+	repo = strings.TrimSuffix(parts[1], ".git")
+	if repo == "" {
+		return "", "", "", false
+	}
+	return strings.ToLower(host), parts[0], repo, true
+}
+
+// stripURLCredentials removes a password or token embedded in an HTTPS
+// remote URL so the URL is safe to display
+func stripURLCredentials(remote string) string {
+	u, err := url.Parse(remote)
+	if err != nil || u.User == nil {
+		return remote
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.User(u.User.Username())
+	}
+	return u.String()
+}
+
+// findGitDir walks up from dir to the repository's git directory, following
+// the gitdir: pointer used by worktrees and submodules
+func findGitDir(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		candidate := filepath.Join(dir, ".git")
+		info, err := os.Stat(candidate)
+		if err == nil {
+			if info.IsDir() {
+				return candidate, nil
+			}
+			data, err := os.ReadFile(candidate)
+			if err != nil {
+				return "", err
+			}
+			gitDir := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(data)), "gitdir:"))
+			if !filepath.IsAbs(gitDir) {
+				gitDir = filepath.Join(dir, gitDir)
+			}
+			return gitDir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("not inside a git repository")
+		}
+		dir = parent
+	}
+}
+
+// readGitRemotes lists the GitHub-style remotes of the repository containing
+// dir, with origin first. Remotes whose URL cannot be parsed are skipped.
+func readGitRemotes(dir string) ([]gitRemote, error) {
+	gitDir, err := findGitDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	configPath := filepath.Join(gitDir, "config")
+	// Worktrees keep the shared config in the common directory
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		configPath = filepath.Join(common, "config")
+	}
+
+	file, err := os.Open(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading git config: %v", err)
+	}
+	defer file.Close()
+
+	var remotes []gitRemote
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			current = ""
+			section := strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			if name, ok := strings.CutPrefix(section, "remote "); ok {
+				current = strings.Trim(strings.TrimSpace(name), `"`)
+			}
+			continue
+		}
+		if current == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(key), "url") {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if host, owner, repo, ok := parseRemoteURL(value); ok {
+			remotes = append(remotes, gitRemote{Name: current, URL: stripURLCredentials(value), Host: host, Owner: owner, Repo: repo})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading git config: %v", err)
+	}
+
+	for i, remote := range remotes {
+		if remote.Name == "origin" && i > 0 {
+			remotes = append([]gitRemote{remote}, append(remotes[:i:i], remotes[i+1:]...)...)
+			break
+		}
+	}
+	return remotes, nil
+}
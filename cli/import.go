@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"workflo/githubactions"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runSecretsImport uploads values from a dotenv file, letting the user pick
+// which entries to include and prefix their names
+func runSecretsImport(args []string) error {
+	var opts secretsFlags
+	fs := newSecretsFlagSet("import", &opts)
+	prefix := fs.String("prefix", "", "prefix added to every secret name, e.g. PROD")
+	yes := fs.Bool("yes", false, "import every entry without asking")
+	var scopes scopeFlag
+	registerScopeFlag(fs, &scopes)
+	files, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(files) != 1 {
+		return fmt.Errorf("exactly one dotenv file is required")
+	}
+
+	entries, err := readDotenv(files[0])
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(stdout, "No entries found in %s.\n", files[0])
+		return nil
+	}
+
+	ctx := context.Background()
+	client, owner, repo, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	stores := scopes.stores(client, owner, repo)
+
+	selected := make([]int, len(entries))
+	for i := range selected {
+		selected[i] = i
+	}
+	if !*yes {
+		// Existing secrets are only flagged in the preview, so a failed
+		// lookup is not fatal
+		existing := make(map[string]bool)
+		for _, store := range stores {
+			names, _ := store.Names(ctx)
+			for name := range names {
+				existing[name] = true
+			}
+		}
+		final, err := tea.NewProgram(newImportModel(entries, *prefix, existing)).Run()
+		if err != nil {
+			return fmt.Errorf("error running import selection: %v", err)
+		}
+		result := final.(importModel)
+		if !result.confirmed {
+			fmt.Fprintln(stdout, "Import cancelled, no secrets were changed.")
+			return nil
+		}
+		selected = result.list.Selected()
+		*prefix = result.prefixInput.Value()
+	}
+	if len(selected) == 0 {
+		fmt.Fprintln(stdout, "No entries selected.")
+		return nil
+	}
+
+	keys := make([]string, len(selected))
+	for j, i := range selected {
+		keys[j] = entries[i].Key
+	}
+	if collisions := nameCollisions(*prefix, keys); len(collisions) > 0 {
+		return fmt.Errorf("entries map to the same secret name: %s", strings.Join(collisions, "; "))
+	}
+
+	secrets := make(map[string]string, len(selected))
+	defer wipeSecrets(secrets)
+	for _, i := range selected {
+		secrets[prefixedName(*prefix, entries[i].Key)] = entries[i].Value
+	}
+	fmt.Fprintf(stdout, "Importing %d secret(s) from %s into %s/%s:\n", len(secrets), files[0], owner, repo)
+	return uploadToStores(ctx, stores, secrets)
+}
+
+// prefixedName joins prefix and name with an underscore and normalizes the
+// result to GitHub's secret naming rules
+func prefixedName(prefix, name string) string {
+	if prefix = githubactions.SanitizeSecretPrefix(prefix); prefix == "" {
+		return githubactions.SanitizeSecretName(name)
+	}
+	return githubactions.SanitizeSecretName(prefix + "_" + name)
+}
+
+// nameCollisions describes the keys whose secret names clash once prefixed
+// and sanitized, e.g. "db.url, DB_URL -> DB_URL"
+func nameCollisions(prefix string, keys []string) []string {
+	byName := make(map[string][]string)
+	var names []string
+	for _, key := range keys {
+		name := prefixedName(prefix, key)
+		if byName[name] == nil {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], key)
+	}
+	var collisions []string
+	for _, name := range names {
+		if len(byName[name]) > 1 {
+			collisions = append(collisions, strings.Join(byName[name], ", ")+" -> "+name)
+		}
+	}
+	return collisions
+}
+
+// maskValue describes a secret value without revealing it
+func maskValue(value string) string {
+	if value == "" {
+		return "(empty)"
+	}
+	desc := fmt.Sprintf("%d chars", len(value))
+	if lines := strings.Count(value, "\n") + 1; lines > 1 {
+		desc += fmt.Sprintf(", %d lines", lines)
+	}
+	return "******** (" + desc + ")"
+}
+
+// importModel previews a dotenv file as an include/exclude checklist, then
+// asks for an optional name prefix
+type importModel struct {
+	entries        []dotenvEntry
+	existing       map[string]bool // secrets already in the repository
+	list           checklist
+	prefixInput    textinput.Model
+	choosingPrefix bool
+	confirmed      bool
+}
+
+func newImportModel(entries []dotenvEntry, prefix string, existing map[string]bool) importModel {
+	labels := make([]string, len(entries))
+	for i, entry := range entries {
+		labels[i] = fmt.Sprintf("%s = %s", entry.Key, maskValue(entry.Value))
+	}
+
+	input := textinput.New()
+	input.Placeholder = "e.g. PROD (leave empty for none)"
+	input.CharLimit = 64
+	input.Width = 50
+	input.SetValue(prefix)
+
+	return importModel{
+		entries:     entries,
+		existing:    existing,
+		list:        newChecklist("Select the entries to upload as secrets:", labels, true),
+		prefixInput: input,
+	}
+}
+
+// Init implements tea.Model
+func (m importModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update toggles entries, then edits the prefix and confirms
+func (m importModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if !m.choosingPrefix {
+		switch msg := msg.(type) {
+		case tea.KeyMsg:
+			switch msg.String() {
+			case "enter":
+				m.choosingPrefix = true
+				m.prefixInput.Focus()
+				return m, textinput.Blink
+			case "ctrl+c", "q":
+				return m, tea.Quit
+			}
+		}
+		m.list = m.list.Update(msg)
+		return m, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if len(nameCollisions(m.prefixInput.Value(), m.selectedKeys())) > 0 {
+				return m, nil
+			}
+			m.confirmed = true
+			return m, tea.Quit
+		case "esc":
+			m.choosingPrefix = false
+			m.prefixInput.Blur()
+			return m, nil
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.prefixInput, cmd = m.prefixInput.Update(msg)
+	return m, cmd
+}
+
+// selectedKeys returns the dotenv keys of the selected entries
+func (m importModel) selectedKeys() []string {
+	var keys []string
+	for _, i := range m.list.Selected() {
+		keys = append(keys, m.entries[i].Key)
+	}
+	return keys
+}
+
+// View renders the checklist, or the prefix input with the resulting names
+func (m importModel) View() string {
+	if !m.choosingPrefix {
+		return m.list.View() + "(q to cancel)\n"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Optional prefix for the secret names:\n\n%s\n\nSecrets to upload:\n", m.prefixInput.View())
+	keys := m.selectedKeys()
+	for _, key := range keys {
+		name := prefixedName(m.prefixInput.Value(), key)
+		line := name
+		if githubactions.SanitizeSecretName(key) != key {
+			line += "  (from " + key + ")"
+		}
+		if m.existing[name] {
+			line += "  (exists, will be overwritten)"
+		}
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	if collisions := nameCollisions(m.prefixInput.Value(), keys); len(collisions) > 0 {
+		fmt.Fprintf(&b, "\nName collisions, deselect one of each: %s\n", strings.Join(collisions, "; "))
+	}
+	b.WriteString("\n(Press Enter to upload, Esc to go back)\n")
+	return b.String()
+}
@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"workflo/githubactions"
+
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -38,12 +40,32 @@ func NewModel() model {
 		item("Other (Enter custom cron)"),
 	}
 
-	// Cloud providers
-	cloudProviders := []list.Item{
-		item("AWS"),
-		item("Azure"),
-		item("GCP"),
-		item("None of the Above"),
+	// Cloud providers come from the githubactions registry
+	var cloudProviders []list.Item
+	for _, provider := range githubactions.CloudProviders {
+		cloudProviders = append(cloudProviders, item(provider.Name()))
+	}
+	cloudProviders = append(cloudProviders, item("None of the Above"))
+
+	// Authentication modes for the selected cloud provider
+	authModes := []list.Item{
+		item("Access keys"),
+		item("OIDC (keyless)"),
+	}
+
+	// GitHub Environments the secrets and deploy job can be scoped to
+	environments := []list.Item{
+		item(noEnvironment),
+		item("production"),
+		item("staging"),
+		item("development"),
+		item(otherEnvironment),
+	}
+
+	// Deployment branch policies for the environment
+	branchPolicies := []list.Item{
+		item("All branches"),
+		item("Protected branches only"),
 	}
 
 	// Yes/No options for Git Checkout and Configure Secrets
@@ -59,10 +81,15 @@ func NewModel() model {
 	schedule.SetShowHelp(false)
 
 	cloud := list.New(cloudProviders, list.NewDefaultDelegate(), 50, 15)
-	cloud.Title = "Select a Cloud provider to configure credentials:"
+	cloud.Title = "Select a Cloud provider or deployment platform to configure credentials:"
 	cloud.SetShowStatusBar(false)
 	cloud.SetShowHelp(false)
 
+	cloudAuthMode := list.New(authModes, list.NewDefaultDelegate(), 50, 7)
+	cloudAuthMode.Title = "How should the workflow authenticate to the cloud provider?"
+	cloudAuthMode.SetShowStatusBar(false)
+	cloudAuthMode.SetShowHelp(false)
+
 	cron := list.New(cronOptions, list.NewDefaultDelegate(), 50, 15)
 	cron.Title = "Select Cron Frequency:"
 	cron.SetShowStatusBar(false)
@@ -78,6 +105,42 @@ func NewModel() model {
 	configureSecretsOption.SetShowStatusBar(false)
 	configureSecretsOption.SetShowHelp(false)
 
+	environmentOption := list.New(environments, list.NewDefaultDelegate(), 50, 12)
+	environmentOption.Title = "Scope the secrets and deploy job to a GitHub Environment?"
+	environmentOption.SetShowStatusBar(false)
+	environmentOption.SetShowHelp(false)
+
+	branchPolicyOption := list.New(branchPolicies, list.NewDefaultDelegate(), 50, 7)
+	branchPolicyOption.Title = "Which branches may deploy to this environment?"
+	branchPolicyOption.SetShowStatusBar(false)
+	branchPolicyOption.SetShowHelp(false)
+
+	anotherAccountOption := list.New(yesNoOptions, list.NewDefaultDelegate(), 50, 7)
+	anotherAccountOption.Title = "Deploy to another environment with a different cloud account?"
+	anotherAccountOption.SetShowStatusBar(false)
+	anotherAccountOption.SetShowHelp(false)
+
+	// Offer a Docker job only when the project has a Dockerfile
+	dockerfile := githubactions.FindDockerfile(".")
+	dockerOption := list.New(yesNoOptions, list.NewDefaultDelegate(), 50, 7)
+	dockerOption.Title = "Found " + dockerfile + ". Add a job that builds and pushes a Docker image?"
+	dockerOption.SetShowStatusBar(false)
+	dockerOption.SetShowHelp(false)
+
+	dockerPlatformOptions := newChecklist("Select the platforms to build the image for:", githubactions.DockerPlatforms, false)
+	dockerPlatformOptions.checked[0] = true
+
+	// Git remotes used to prefill the repository owner and name
+	gitRemotes, _ := readGitRemotes(".")
+	var remoteItems []list.Item
+	for _, remote := range gitRemotes {
+		remoteItems = append(remoteItems, item(remote.String()))
+	}
+	gitRemoteOption := list.New(remoteItems, list.NewDefaultDelegate(), 50, 10)
+	gitRemoteOption.Title = "Select the git remote to configure:"
+	gitRemoteOption.SetShowStatusBar(false)
+	gitRemoteOption.SetShowHelp(false)
+
 	// Initialize text inputs
 	ti := textinput.New()
 	ti.Placeholder = "Enter a name for this workflow"
@@ -97,7 +160,7 @@ func NewModel() model {
 
 	// GitHub username input
 	githubUsernameInput := textinput.New()
-	githubUsernameInput.Placeholder = "Enter your GitHub username"
+	githubUsernameInput.Placeholder = "Enter the user or organization owning the repository"
 	githubUsernameInput.CharLimit = 100
 	githubUsernameInput.Width = 40
 
@@ -110,84 +173,93 @@ func NewModel() model {
 	// GitHub Personal Access Token input
 	githubTokenInput := textinput.New()
 	githubTokenInput.Placeholder = "Enter your GitHub Personal Access Token"
-	githubTokenInput.CharLimit = 100
+	githubTokenInput.CharLimit = 255
 	githubTokenInput.Width = 40
-
-	// AWS credential inputs
-	awsAccessKeyIDInput := textinput.New()
-	awsAccessKeyIDInput.Placeholder = "Enter AWS Access Key ID"
-	awsAccessKeyIDInput.CharLimit = 128
-	awsAccessKeyIDInput.Width = 50
-
-	awsSecretAccessKeyInput := textinput.New()
-	awsSecretAccessKeyInput.Placeholder = "Enter AWS Secret Access Key"
-	awsSecretAccessKeyInput.CharLimit = 128
-	awsSecretAccessKeyInput.Width = 50
-
-	awsRegionInput := textinput.New()
-	awsRegionInput.Placeholder = "Enter AWS Region"
-	awsRegionInput.CharLimit = 64
-	awsRegionInput.Width = 50
-
-	// Azure credential inputs
-	azureClientIDInput := textinput.New()
-	azureClientIDInput.Placeholder = "Enter Azure Client ID"
-	azureClientIDInput.CharLimit = 128
-	azureClientIDInput.Width = 50
-
-	azureClientSecretInput := textinput.New()
-	azureClientSecretInput.Placeholder = "Enter Azure Client Secret"
-	azureClientSecretInput.CharLimit = 128
-	azureClientSecretInput.Width = 50
-
-	azureTenantIDInput := textinput.New()
-	azureTenantIDInput.Placeholder = "Enter Azure Tenant ID"
-	azureTenantIDInput.CharLimit = 128
-	azureTenantIDInput.Width = 50
-
-	azureSubscriptionIDInput := textinput.New()
-	azureSubscriptionIDInput.Placeholder = "Enter Azure Subscription ID"
-	azureSubscriptionIDInput.CharLimit = 128
-	azureSubscriptionIDInput.Width = 50
-
-	// GCP credential inputs
-	gcpServiceAccountKeyInput := textinput.New()
-	gcpServiceAccountKeyInput.Placeholder = "Enter GCP Service Account Key (JSON)"
-	gcpServiceAccountKeyInput.CharLimit = 5000
-	gcpServiceAccountKeyInput.Width = 50
-
-	gcpProjectIDInput := textinput.New()
-	gcpProjectIDInput.Placeholder = "Enter GCP Project ID"
-	gcpProjectIDInput.CharLimit = 128
-	gcpProjectIDInput.Width = 50
+	githubTokenInput.EchoMode = textinput.EchoPassword
+	githubTokenInput.EchoCharacter = '*'
 
 	return model{
-		state:                     stateWorkflowName,
-		supportedSched:            schedule,
-		cronFrequency:             cron,
-		supportedCloud:            cloud,
-		supportedLang:             lang,
-		textInput:                 ti,
-		runsOnInput:               ro,
-		gitCheckoutOption:         gitCheckoutOption,
-		configureSecretsOption:    configureSecretsOption,
-		gitBranchInput:            gb,
-		githubUsernameInput:       githubUsernameInput,
-		githubRepoNameInput:       githubRepoNameInput,
-		githubTokenInput:          githubTokenInput,
-		awsAccessKeyIDInput:       awsAccessKeyIDInput,
-		awsSecretAccessKeyInput:   awsSecretAccessKeyInput,
-		awsRegionInput:            awsRegionInput,
-		azureClientIDInput:        azureClientIDInput,
-		azureClientSecretInput:    azureClientSecretInput,
-		azureTenantIDInput:        azureTenantIDInput,
-		azureSubscriptionIDInput:  azureSubscriptionIDInput,
-		gcpServiceAccountKeyInput: gcpServiceAccountKeyInput,
-		gcpProjectIDInput:         gcpProjectIDInput,
-		awsSecrets:                make(map[string]string),
-		azureSecrets:              make(map[string]string),
-		gcpSecrets:                make(map[string]string),
+		state:                  stateWorkflowName,
+		supportedSched:         schedule,
+		cronFrequency:          cron,
+		supportedCloud:         cloud,
+		cloudAuthMode:          cloudAuthMode,
+		supportedLang:          lang,
+		textInput:              ti,
+		runsOnInput:            ro,
+		gitCheckoutOption:      gitCheckoutOption,
+		configureSecretsOption: configureSecretsOption,
+		environmentOption:      environmentOption,
+		branchPolicyOption:     branchPolicyOption,
+		anotherAccountOption:   anotherAccountOption,
+		gitBranchInput:         gb,
+		gitRemotes:             gitRemotes,
+		gitRemoteOption:        gitRemoteOption,
+		githubUsernameInput:    githubUsernameInput,
+		githubRepoNameInput:    githubRepoNameInput,
+		githubTokenInput:       githubTokenInput,
+		credentialValues:       make(map[string]string),
+		dockerfile:             dockerfile,
+		dockerOption:           dockerOption,
+		dockerPlatformOptions:  dockerPlatformOptions,
+	}
+}
+
+// newCredentialInput builds the text input for a cloud provider credential field
+func newCredentialInput(field githubactions.CredentialField) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = field.Placeholder
+	input.CharLimit = field.CharLimit
+	input.Width = 50
+	if field.Sensitive {
+		input.EchoMode = textinput.EchoPassword
+		input.EchoCharacter = '*'
+	}
+	input.Focus()
+	return input
+}
+
+// newEnvironmentInput builds the text input used for the environment's name
+// and protection rules
+func newEnvironmentInput(placeholder string) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = placeholder
+	input.CharLimit = 255
+	input.Width = 50
+	input.Focus()
+	return input
+}
+
+// newDeployTargetList builds the list of deployments a provider offers
+func newDeployTargetList(provider githubactions.CloudProvider) list.Model {
+	var targets []list.Item
+	for _, target := range provider.DeployTargets() {
+		targets = append(targets, item(target.Name))
 	}
+	targets = append(targets, item("Skip deployment"))
+
+	deployTargets := list.New(targets, list.NewDefaultDelegate(), 50, 15)
+	deployTargets.Title = "Select what to deploy to " + provider.Name() + " (runs on the default branch):"
+	deployTargets.SetShowStatusBar(false)
+	deployTargets.SetShowHelp(false)
+	return deployTargets
+}
+
+// newDockerRegistryList builds the list of registries usable with the
+// configured cloud provider. GHCR only needs GITHUB_TOKEN and is always offered.
+func newDockerRegistryList(provider githubactions.CloudProvider) list.Model {
+	var registries []list.Item
+	for _, registry := range githubactions.DockerRegistries {
+		if registry.Provider == "" || (provider != nil && registry.Provider == provider.Name()) {
+			registries = append(registries, item(registry.Name))
+		}
+	}
+
+	dockerRegistries := list.New(registries, list.NewDefaultDelegate(), 50, 10)
+	dockerRegistries.Title = "Select the registry to push the image to:"
+	dockerRegistries.SetShowStatusBar(false)
+	dockerRegistries.SetShowHelp(false)
+	return dockerRegistries
 }
 
 // Init initializes the program and starts text input blinking
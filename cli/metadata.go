@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// metadataEnv overrides the path of the secret metadata file
+const metadataEnv = "WORKFLO_METADATA"
+
+// defaultMaxSecretAge is used when neither --max-age nor the config file
+// set an age
+const defaultMaxSecretAge = "90d"
+
+// secretMetadata records when secrets were last rotated, keyed by store
+// (e.g. owner/name) and secret name. Values are never recorded.
+type secretMetadata struct {
+	Stores map[string]map[string]secretRecord `yaml:"stores"`
+}
+
+// secretRecord is what is known about one secret
+type secretRecord struct {
+	Rotated time.Time `yaml:"rotated"`
+}
+
+// metadataPath returns the location of the metadata file, next to the
+// config file by default
+func metadataPath() (string, error) {
+	if path := os.Getenv(metadataEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "workflo", "secrets.yml"), nil
+}
+
+// loadSecretMetadata reads the metadata file. A missing file gives empty
+// metadata.
+func loadSecretMetadata() (secretMetadata, error) {
+	md := secretMetadata{Stores: make(map[string]map[string]secretRecord)}
+	path, err := metadataPath()
+	if err != nil {
+		return md, fmt.Errorf("error locating secret metadata: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return md, nil
+	}
+	if err != nil {
+		return md, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, &md); err != nil {
+		return md, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if md.Stores == nil {
+		md.Stores = make(map[string]map[string]secretRecord)
+	}
+	return md, nil
+}
+
+// save writes the metadata file and returns its path
+func (md secretMetadata) save() (string, error) {
+	path, err := metadataPath()
+	if err != nil {
+		return "", fmt.Errorf("error locating secret metadata: %v", err)
+	}
+	data, err := yaml.Marshal(md)
+	if err != nil {
+		return "", fmt.Errorf("error encoding secret metadata: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return path, nil
+}
+
+// recordRotation notes that a secret in store was rotated at the given time
+func (md secretMetadata) recordRotation(store, name string, at time.Time) {
+	if md.Stores[store] == nil {
+		md.Stores[store] = make(map[string]secretRecord)
+	}
+	md.Stores[store][name] = secretRecord{Rotated: at.UTC().Truncate(time.Second)}
+}
+
+// rotated returns when a secret was last rotated, if workflo rotated it
+func (md secretMetadata) rotated(store, name string) (time.Time, bool) {
+	record, ok := md.Stores[store][name]
+	return record.Rotated, ok
+}
+
+// parseAge parses an age such as 90d, 12w or a Go duration like 36h
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if n, err := strconv.Atoi(strings.TrimSuffix(value, suffix)); strings.HasSuffix(value, suffix) && err == nil && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age <= 0 {
+		return 0, fmt.Errorf("invalid age %q, expected e.g. 90d, 12w or 36h", value)
+	}
+	return age, nil
+}
+
+// formatAge renders an age in days when it is a whole number of them
+func formatAge(age time.Duration) string {
+	day := 24 * time.Hour
+	if age%day == 0 {
+		return fmt.Sprintf("%d days", age/day)
+	}
+	return age.String()
+}
+
+// maxSecretAge resolves the age after which secrets are flagged: the flag,
+// then the config file, then the default
+func maxSecretAge(flagValue string) (time.Duration, error) {
+	if flagValue == "" {
+		if cfg, err := loadConfig(); err == nil {
+			flagValue = cfg.MaxSecretAge
+		}
+	}
+	if flagValue == "" {
+		flagValue = defaultMaxSecretAge
+	}
+	return parseAge(flagValue)
+}
@@ -2,6 +2,8 @@
 package cli
 
 import (
+	"workflo/githubactions"
+
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 )
@@ -19,66 +21,105 @@ const (
 	stateGitCheckoutOption
 	stateGitBranchSelection
 	stateCloudProvider
-	stateConfigureAWSCredentials
-	stateConfigureAWSAccessKeyID
-	stateConfigureAWSSecretAccessKey
-	stateConfigureAWSRegion
-	stateConfigureAzureCredentials
-	stateConfigureAzureClientID
-	stateConfigureAzureClientSecret
-	stateConfigureAzureTenantID
-	stateConfigureAzureSubscriptionID
-	stateConfigureGCPCredentials
-	stateConfigureGCPServiceAccountKey
-	stateConfigureGCPProjectID
+	stateCloudAuthMode
+	stateCloudCredentials
+	stateVariableFields
+	stateDeployTarget
+	stateDockerOption
+	stateDockerRegistry
+	stateDockerPlatforms
+	stateEnvironment
+	stateEnvironmentName
+	stateEnvironmentReviewers
+	stateEnvironmentWaitTimer
+	stateEnvironmentBranchPolicy
+	stateAnotherAccount
+	stateSecretNaming
 	stateConfigureSecretsOption
+	stateGitRemote
 	stateGitHubUsername
 	stateGitHubRepoName
 	stateGitHubToken
+	stateSecretPreview
 	stateComplete
+	stateUploading
+	stateUploadReport
 )
 
 // Model struct to store the state and components
 type model struct {
-	state                     state
-	supportedSched            list.Model
-	supportedCloud            list.Model
-	cronFrequency             list.Model
-	supportedLang             list.Model
-	gitCheckoutOption         list.Model
-	configureSecretsOption    list.Model
-	textInput                 textinput.Model
-	runsOnInput               textinput.Model
-	gitBranchInput            textinput.Model
-	githubUsernameInput       textinput.Model
-	githubRepoNameInput       textinput.Model
-	githubTokenInput          textinput.Model
-	awsAccessKeyIDInput       textinput.Model
-	awsSecretAccessKeyInput   textinput.Model
-	awsRegionInput            textinput.Model
-	azureClientIDInput        textinput.Model
-	azureClientSecretInput    textinput.Model
-	azureTenantIDInput        textinput.Model
-	azureSubscriptionIDInput  textinput.Model
-	gcpServiceAccountKeyInput textinput.Model
-	gcpProjectIDInput         textinput.Model
-	workflowName              string
-	workflowNameUpper         string
-	schedule                  string
-	cloud                     string
-	language                  string
-	awsRegion                 string
-	customCron                string
-	runsOn                    string
-	gitCheckout               bool
-	gitBranch                 string
-	awsSecrets                map[string]string
-	azureSecrets              map[string]string
-	gcpSecrets                map[string]string
-	configureSecrets          bool
-	githubUsername            string
-	githubRepoName            string
-	githubToken               string
+	state                  state
+	supportedSched         list.Model
+	supportedCloud         list.Model
+	cloudAuthMode          list.Model
+	deployTargets          list.Model
+	dockerOption           list.Model
+	dockerRegistries       list.Model
+	dockerPlatformOptions  checklist
+	variableOptions        checklist
+	environmentOption      list.Model
+	branchPolicyOption     list.Model
+	anotherAccountOption   list.Model
+	secretNamingOption     list.Model
+	cronFrequency          list.Model
+	supportedLang          list.Model
+	gitCheckoutOption      list.Model
+	configureSecretsOption list.Model
+	gitRemoteOption        list.Model
+	textInput              textinput.Model
+	runsOnInput            textinput.Model
+	gitBranchInput         textinput.Model
+	githubUsernameInput    textinput.Model
+	githubRepoNameInput    textinput.Model
+	githubTokenInput       textinput.Model
+	credentialInput        textinput.Model
+	environmentInput       textinput.Model
+	workflowName           string
+	workflowNameUpper      string
+	schedule               string
+	cloudProvider          githubactions.CloudProvider
+	oidc                   bool
+	credentialFields       []githubactions.CredentialField
+	credentialIndex        int
+	credentialValues       map[string]string
+	credentialError        string
+	variableFields         []githubactions.CredentialField
+	variables              map[string]bool
+	variablesAsked         bool
+	variableError          string
+	deployTarget           *githubactions.DeployTarget
+	deployTargetChosen     bool
+	dockerfile             string
+	dockerAsked            bool
+	dockerRegistry         *githubactions.DockerRegistry
+	dockerPlatforms        []string
+	environment            environmentConfig
+	environmentError       string
+	accounts               []cloudAccount
+	secretNaming           string
+	namingChoices          []string
+	language               string
+	customCron             string
+	runsOn                 string
+	gitCheckout            bool
+	gitBranch              string
+	configureSecrets       bool
+	gitRemotes             []gitRemote
+	gitRemote              *gitRemote
+	gitRemoteWarning       string
+	githubServer           githubServer
+	githubUsername         string
+	githubRepoName         string
+	githubToken            string
+	githubTokenSource      string
+	previews               []secretPreview
+	existingChecked        bool
+	existingSecretsErr     error
+	uploads                []secretUpload
+	uploadFailed           bool
+	uploadMessages         []string
+	rollingBack            bool
+	rollbackUploads        []secretUpload
 }
 
 // item struct implementing list.Item interface
@@ -0,0 +1,419 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// Organization secret visibilities
+const (
+	orgVisibilityAll      = "all"
+	orgVisibilityPrivate  = "private"
+	orgVisibilitySelected = "selected"
+)
+
+const orgSecretsUsage = `Usage: workflo secrets org <command> --org ORG [flags]
+
+Commands:
+  list                List the organization's secrets, their visibility and selected repositories
+  set NAME...         Create or update secrets with --visibility all, private or selected;
+                      with selected, pick the repositories or give them with --select-repo
+  delete NAME...      Delete secrets
+  repos NAME          Show the repositories that can read a secret with selected visibility;
+                      change them with --add, --remove or --pick
+
+Without --org, the owner of --repo or of the origin remote is used. The token needs the
+admin:org scope.
+`
+
+// orgFlags are the flags shared by the org secrets commands
+type orgFlags struct {
+	secretsFlags
+	org string
+}
+
+func newOrgFlagSet(name string, opts *orgFlags) *flag.FlagSet {
+	fs := newSecretsFlagSet("org "+name, &opts.secretsFlags)
+	fs.StringVar(&opts.org, "org", "", "organization (defaults to the owner of the repository)")
+	return fs
+}
+
+// client builds the GitHub client and resolves the organization
+func (opts orgFlags) client(ctx context.Context) (*github.Client, string, error) {
+	if opts.org == "" {
+		client, owner, _, err := opts.secretsFlags.client(ctx)
+		return client, owner, err
+	}
+	client, err := newGitHubClient(ctx, opts.discoverToken(opts.server), opts.server)
+	return client, opts.org, err
+}
+
+// repoNames collects repeated repository flags
+type repoNames []string
+
+func (r *repoNames) String() string { return strings.Join(*r, ",") }
+
+func (r *repoNames) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// runSecretsOrg manages organization secrets
+func runSecretsOrg(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, orgSecretsUsage)
+		return fmt.Errorf("missing org command")
+	}
+
+	switch args[0] {
+	case "list":
+		return runOrgSecretsList(args[1:])
+	case "set":
+		return runOrgSecretsSet(args[1:])
+	case "delete":
+		return runOrgSecretsDelete(args[1:])
+	case "repos":
+		return runOrgSecretsRepos(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, orgSecretsUsage)
+		return nil
+	default:
+		fmt.Fprint(stderr, orgSecretsUsage)
+		return fmt.Errorf("unknown org command %q", args[0])
+	}
+}
+
+// runOrgSecretsList prints every organization secret with its visibility
+func runOrgSecretsList(args []string) error {
+	var opts orgFlags
+	fs := newOrgFlagSet("list", &opts)
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, org, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	var secrets []*github.Secret
+	listOpts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Actions.ListOrgSecrets(ctx, org, listOpts)
+		if err != nil {
+			return fmt.Errorf("error listing secrets of %s: %v", org, err)
+		}
+		secrets = append(secrets, page.Secrets...)
+		if resp.NextPage == 0 {
+			break
+		}
+		listOpts.Page = resp.NextPage
+	}
+	if len(secrets) == 0 {
+		fmt.Fprintf(stdout, "No secrets found in organization %s.\n", org)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVISIBILITY\tUPDATED\tREPOSITORIES")
+	for _, secret := range secrets {
+		repos := "-"
+		if secret.Visibility == orgVisibilitySelected {
+			selected, err := selectedRepos(ctx, client, org, secret.Name)
+			if err != nil {
+				return err
+			}
+			repos = strings.Join(repoNamesOf(selected), ", ")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", secret.Name, secret.Visibility, secret.UpdatedAt.Format("2006-01-02 15:04:05"), repos)
+	}
+	return w.Flush()
+}
+
+// runOrgSecretsSet creates or updates organization secrets with the given
+// visibility. Values are read like `secrets set`.
+func runOrgSecretsSet(args []string) error {
+	var opts orgFlags
+	fs := newOrgFlagSet("set", &opts)
+	visibility := fs.String("visibility", "", "which repositories can read the secrets: all, private or selected")
+	var selectRepos repoNames
+	fs.Var(&selectRepos, "select-repo", "repository that can read the secrets with --visibility selected (repeatable)")
+	value := fs.String("value", "", "secret value (visible in shell history, prefer --stdin or the prompt)")
+	stdin := fs.Bool("stdin", false, "read the secret value from standard input")
+	literal := fs.Bool("literal", false, "upload values starting with exec:, file: or env: as they are instead of resolving them")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("at least one secret name is required")
+	}
+	if err := validateSecretNames(names); err != nil {
+		return err
+	}
+	switch *visibility {
+	case orgVisibilityAll, orgVisibilityPrivate:
+		if len(selectRepos) > 0 {
+			return fmt.Errorf("--select-repo needs --visibility selected")
+		}
+	case orgVisibilitySelected:
+	default:
+		return fmt.Errorf("--visibility all, private or selected is required")
+	}
+	if (*value != "" || *stdin) && len(names) > 1 {
+		return fmt.Errorf("--value and --stdin set a single secret")
+	}
+
+	ctx := context.Background()
+	client, org, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	store := orgSecretStore{client: client, org: org, visibility: *visibility}
+	if *visibility == orgVisibilitySelected {
+		var repos []*github.Repository
+		if len(selectRepos) > 0 {
+			repos, err = lookupOrgRepos(ctx, client, org, selectRepos)
+		} else {
+			repos, err = pickOrgRepos(ctx, client, org, "Select the repositories that can read "+strings.Join(names, ", ")+":", nil)
+		}
+		if err != nil {
+			return err
+		}
+		if len(repos) == 0 {
+			return fmt.Errorf("no repositories selected")
+		}
+		store.repoIDs = repoIDsOf(repos)
+	}
+
+	secrets := make(map[string]string)
+	defer wipeSecrets(secrets)
+	if err := readSecretValues(secrets, names, *value, *stdin); err != nil {
+		return err
+	}
+	if !*literal {
+		resolved, err := resolveValueRefs(ctx, secrets)
+		if err != nil {
+			return err
+		}
+		defer wipeSecrets(resolved)
+		secrets = resolved
+	}
+	return printReport(uploadSecrets(ctx, store, secrets))
+}
+
+// runOrgSecretsDelete deletes organization secrets
+func runOrgSecretsDelete(args []string) error {
+	var opts orgFlags
+	fs := newOrgFlagSet("delete", &opts)
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("at least one secret name is required")
+	}
+	if err := validateSecretNames(names); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, org, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, err := client.Actions.DeleteOrgSecret(ctx, org, name); err != nil {
+			return fmt.Errorf("error deleting secret %s: %v", name, err)
+		}
+		fmt.Fprintf(stdout, "Deleted %s from organization %s.\n", name, org)
+	}
+	return nil
+}
+
+// runOrgSecretsRepos shows or changes the repositories that can read a
+// secret with selected visibility
+func runOrgSecretsRepos(args []string) error {
+	var opts orgFlags
+	fs := newOrgFlagSet("repos", &opts)
+	var add, remove repoNames
+	fs.Var(&add, "add", "give a repository access (repeatable)")
+	fs.Var(&remove, "remove", "take away a repository's access (repeatable)")
+	pick := fs.Bool("pick", false, "choose the repositories from a list of the organization's repositories")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) != 1 {
+		return fmt.Errorf("exactly one secret name is required")
+	}
+	name := names[0]
+	if *pick && len(add)+len(remove) > 0 {
+		return fmt.Errorf("--pick cannot be combined with --add or --remove")
+	}
+
+	ctx := context.Background()
+	client, org, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	secret, _, err := client.Actions.GetOrgSecret(ctx, org, name)
+	if err != nil {
+		return fmt.Errorf("error getting secret %s: %v", name, err)
+	}
+	if secret.Visibility != orgVisibilitySelected {
+		return fmt.Errorf("%s is visible to %s repositories; set it again with --visibility selected to choose repositories", name, secret.Visibility)
+	}
+	current, err := selectedRepos(ctx, client, org, name)
+	if err != nil {
+		return err
+	}
+
+	var updated []*github.Repository
+	switch {
+	case *pick:
+		checked := make(map[int64]bool)
+		for _, repo := range current {
+			checked[repo.GetID()] = true
+		}
+		if updated, err = pickOrgRepos(ctx, client, org, "Select the repositories that can read "+name+":", checked); err != nil {
+			return err
+		}
+	case len(add)+len(remove) > 0:
+		added, err := lookupOrgRepos(ctx, client, org, add)
+		if err != nil {
+			return err
+		}
+		removed, err := lookupOrgRepos(ctx, client, org, remove)
+		if err != nil {
+			return err
+		}
+		drop := make(map[int64]bool)
+		for _, repo := range removed {
+			drop[repo.GetID()] = true
+		}
+		seen := make(map[int64]bool)
+		for _, repo := range append(current, added...) {
+			if !drop[repo.GetID()] && !seen[repo.GetID()] {
+				seen[repo.GetID()] = true
+				updated = append(updated, repo)
+			}
+		}
+	default:
+		if len(current) == 0 {
+			fmt.Fprintf(stdout, "No repositories can read %s.\n", name)
+		}
+		for _, repo := range repoNamesOf(current) {
+			fmt.Fprintln(stdout, repo)
+		}
+		return nil
+	}
+
+	if _, err := client.Actions.SetSelectedReposForOrgSecret(ctx, org, name, repoIDsOf(updated)); err != nil {
+		return fmt.Errorf("error setting repositories of %s: %v", name, err)
+	}
+	if len(updated) == 0 {
+		fmt.Fprintf(stdout, "No repositories can read %s now.\n", name)
+		return nil
+	}
+	fmt.Fprintf(stdout, "%s can now be read by %s.\n", name, strings.Join(repoNamesOf(updated), ", "))
+	return nil
+}
+
+// selectedRepos lists the repositories that can read a secret with selected
+// visibility
+func selectedRepos(ctx context.Context, client *github.Client, org, name string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		page, resp, err := client.Actions.ListSelectedReposForOrgSecret(ctx, org, name, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing repositories of %s: %v", name, err)
+		}
+		repos = append(repos, page.Repositories...)
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// lookupOrgRepos resolves repository names, given as name or org/name, to
+// the organization's repositories
+func lookupOrgRepos(ctx context.Context, client *github.Client, org string, names []string) ([]*github.Repository, error) {
+	var repos []*github.Repository
+	for _, name := range names {
+		if owner, repo, err := splitRepo(name); err == nil {
+			if !strings.EqualFold(owner, org) {
+				return nil, fmt.Errorf("%s is not a repository of %s", name, org)
+			}
+			name = repo
+		}
+		repo, _, err := client.Repositories.Get(ctx, org, name)
+		if err != nil {
+			return nil, fmt.Errorf("error getting repository %s/%s: %v", org, name, err)
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+// pickOrgRepos lets the user check repositories of the organization, with
+// the repositories in checked ticked to begin with
+func pickOrgRepos(ctx context.Context, client *github.Client, org, title string, checked map[int64]bool) ([]*github.Repository, error) {
+	var all []*github.Repository
+	opts := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing repositories of %s: %v", org, err)
+		}
+		all = append(all, page...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if len(all) == 0 {
+		return nil, fmt.Errorf("no repositories found in %s", org)
+	}
+
+	ticked := make(map[int]bool)
+	for i, repo := range all {
+		if checked[repo.GetID()] {
+			ticked[i] = true
+		}
+	}
+	picked, err := promptPick(title, repoNamesOf(all), ticked)
+	if err != nil {
+		return nil, err
+	}
+	repos := make([]*github.Repository, len(picked))
+	for i, index := range picked {
+		repos[i] = all[index]
+	}
+	return repos, nil
+}
+
+// repoNamesOf returns the names of repos
+func repoNamesOf(repos []*github.Repository) []string {
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.GetName()
+	}
+	return names
+}
+
+// repoIDsOf returns the IDs of repos
+func repoIDsOf(repos []*github.Repository) github.SelectedRepoIDs {
+	ids := make(github.SelectedRepoIDs, len(repos))
+	for i, repo := range repos {
+		ids[i] = repo.GetID()
+	}
+	return ids
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"workflo/githubactions"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// releasePattern matches full semantic version tags such as v4.2.2
+var releasePattern = regexp.MustCompile(`^v?\d+\.\d+\.\d+$`)
+
+// runPin rewrites every `uses:` reference in the workflow directory to a
+// full commit SHA, keeping the human readable version as a trailing comment
+func runPin(args []string) error {
+	fs := flag.NewFlagSet("pin", flag.ContinueOnError)
+	dir := fs.String("dir", githubactions.WorkflowsDir, "directory containing the workflow files")
+	token := fs.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub token used to resolve tags")
+	var server githubServer
+	server.registerFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "print the changes without writing them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := newGitHubClient(ctx, *token, server)
+	if err != nil {
+		return err
+	}
+	resolver := newActionResolver(client)
+
+	files, err := githubactions.WorkflowFiles(*dir)
+	if err != nil {
+		return fmt.Errorf("error reading workflow directory: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", file, err)
+		}
+
+		content := string(data)
+		for _, ref := range githubactions.FindUses(content) {
+			if ref.Ref == "" || githubactions.IsCommitSHA(ref.Ref) {
+				continue
+			}
+			pinned, err := resolver.resolve(ctx, ref.Action, ref.Ref)
+			if err != nil {
+				fmt.Fprintf(stdout, "%s:%d: skipping %s: %v\n", file, ref.Line+1, ref, err)
+				continue
+			}
+			content = githubactions.ReplaceUses(content, ref, pinned.SHA, pinned.Version)
+			fmt.Fprintf(stdout, "%s:%d: %s -> %s # %s\n", file, ref.Line+1, ref, pinned.SHA, pinned.Version)
+		}
+
+		if *dryRun || content == string(data) {
+			continue
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", file, err)
+		}
+	}
+
+	return nil
+}
+
+// pinnedRef is a commit SHA together with the version it was resolved from
+type pinnedRef struct {
+	SHA     string
+	Version string
+}
+
+// actionResolver resolves action tags to commit SHAs through the GitHub API,
+// caching results so repeated references cost a single lookup
+type actionResolver struct {
+	client *github.Client
+	cache  map[string]pinnedRef
+}
+
+func newActionResolver(client *github.Client) *actionResolver {
+	return &actionResolver{
+		client: client,
+		cache:  make(map[string]pinnedRef),
+	}
+}
+
+// resolve finds the commit for action@ref. Catalog actions on their major
+// tag are pinned to the catalog release so the result is reproducible.
+func (r *actionResolver) resolve(ctx context.Context, action, ref string) (pinnedRef, error) {
+	key := action + "@" + ref
+	if pinned, ok := r.cache[key]; ok {
+		return pinned, nil
+	}
+
+	owner, repo, found := strings.Cut(githubactions.ActionRepo(action), "/")
+	if !found {
+		return pinnedRef{}, fmt.Errorf("invalid action %q", action)
+	}
+
+	tag := ref
+	if version, ok := githubactions.LookupAction(action); ok && ref == version.Major {
+		tag = version.Release
+	}
+
+	sha, err := r.commitForRef(ctx, owner, repo, tag)
+	if err != nil {
+		return pinnedRef{}, err
+	}
+
+	pinned := pinnedRef{SHA: sha, Version: tag}
+	if !releasePattern.MatchString(tag) {
+		pinned.Version = r.releaseForCommit(ctx, owner, repo, sha, tag)
+	}
+
+	r.cache[key] = pinned
+	return pinned, nil
+}
+
+// commitForRef looks up a tag, falling back to a branch, and peels annotated
+// tags down to the commit they point at
+func (r *actionResolver) commitForRef(ctx context.Context, owner, repo, ref string) (string, error) {
+	gitRef, resp, err := r.client.Git.GetRef(ctx, owner, repo, "tags/"+ref)
+	if err != nil && resp != nil && resp.StatusCode == http.StatusNotFound {
+		gitRef, _, err = r.client.Git.GetRef(ctx, owner, repo, "heads/"+ref)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error resolving %s/%s@%s: %v", owner, repo, ref, err)
+	}
+
+	object := gitRef.GetObject()
+	for object.GetType() == "tag" {
+		tag, _, err := r.client.Git.GetTag(ctx, owner, repo, object.GetSHA())
+		if err != nil {
+			return "", fmt.Errorf("error resolving annotated tag %s/%s@%s: %v", owner, repo, ref, err)
+		}
+		object = tag.GetObject()
+	}
+
+	if !githubactions.IsCommitSHA(object.GetSHA()) {
+		return "", fmt.Errorf("%s/%s@%s did not resolve to a commit", owner, repo, ref)
+	}
+	return object.GetSHA(), nil
+}
+
+// releaseForCommit finds a full release tag (vX.Y.Z) pointing at the same
+// commit as a moving tag like v4. The original ref is returned if none exists.
+func (r *actionResolver) releaseForCommit(ctx context.Context, owner, repo, sha, fallback string) string {
+	tags, _, err := r.client.Repositories.ListTags(ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return fallback
+	}
+	for _, tag := range tags {
+		if tag.GetCommit().GetSHA() == sha && releasePattern.MatchString(tag.GetName()) {
+			return tag.GetName()
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	checkoutCommit = "1111111111111111111111111111111111111111"
+	checkoutTagObj = "2222222222222222222222222222222222222222"
+	toolCommit     = "3333333333333333333333333333333333333333"
+	branchCommit   = "4444444444444444444444444444444444444444"
+)
+
+// newFakeGitAPI serves the git refs, annotated tags and tag listings the
+// resolver reads, counting the requests it receives
+func newFakeGitAPI(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	refs := map[string]map[string]string{
+		// The catalog release of actions/checkout is an annotated tag
+		"/repos/actions/checkout/git/ref/tags/v4.2.2": {"type": "tag", "sha": checkoutTagObj},
+		"/repos/octo/tool/git/ref/tags/v1":            {"type": "commit", "sha": toolCommit},
+		"/repos/octo/tool/git/ref/heads/main":         {"type": "commit", "sha": branchCommit},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch path := r.URL.Path; {
+		case refs[path] != nil:
+			json.NewEncoder(w).Encode(map[string]interface{}{"ref": path, "object": refs[path]})
+		case path == "/repos/actions/checkout/git/tags/"+checkoutTagObj:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"sha":    checkoutTagObj,
+				"object": map[string]string{"type": "commit", "sha": checkoutCommit},
+			})
+		case path == "/repos/octo/tool/tags":
+			json.NewEncoder(w).Encode([]map[string]interface{}{
+				{"name": "v1", "commit": map[string]string{"sha": toolCommit}},
+				{"name": "v1.3.0", "commit": map[string]string{"sha": toolCommit}},
+				{"name": "v1.2.0", "commit": map[string]string{"sha": "5555555555555555555555555555555555555555"}},
+			})
+		default:
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestActionResolverResolve(t *testing.T) {
+	server, requests := newFakeGitAPI(t)
+	client, err := newGitHubClient(context.Background(), "", githubServer{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := newActionResolver(client)
+
+	tests := []struct {
+		action, ref string
+		want        pinnedRef
+	}{
+		// A catalog major is pinned to the catalog release, peeling the annotated tag
+		{"actions/checkout", "v4", pinnedRef{SHA: checkoutCommit, Version: "v4.2.2"}},
+		// A moving tag is named after the release on the same commit
+		{"octo/tool", "v1", pinnedRef{SHA: toolCommit, Version: "v1.3.0"}},
+		// A branch without a release keeps its name
+		{"octo/tool", "main", pinnedRef{SHA: branchCommit, Version: "main"}},
+	}
+	for _, tt := range tests {
+		got, err := resolver.resolve(context.Background(), tt.action, tt.ref)
+		if err != nil {
+			t.Errorf("resolve(%s@%s): %v", tt.action, tt.ref, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolve(%s@%s) = %+v, want %+v", tt.action, tt.ref, got, tt.want)
+		}
+	}
+
+	before := requests.Load()
+	if _, err := resolver.resolve(context.Background(), "actions/checkout", "v4"); err != nil {
+		t.Fatal(err)
+	}
+	if after := requests.Load(); after != before {
+		t.Errorf("a cached reference made %d more requests", after-before)
+	}
+
+	if _, err := resolver.resolve(context.Background(), "octo/tool", "v9"); err == nil {
+		t.Error("resolve(octo/tool@v9) succeeded for a missing tag")
+	}
+}
+
+func TestPinWritesSHAAndVersionComment(t *testing.T) {
+	server, _ := newFakeGitAPI(t)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "ci.yml")
+	workflow := "steps:\n  - uses: actions/checkout@v4\n  - uses: octo/tool@v1 # the tool\n"
+	if err := os.WriteFile(file, []byte(workflow), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runPin([]string{"--dir", dir, "--token", "", "--api-url", server.URL}); err != nil {
+		t.Fatalf("pin: %v", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"uses: actions/checkout@" + checkoutCommit + " # v4.2.2\n",
+		"uses: octo/tool@" + toolCommit + " # v1.3.0\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("pinned workflow is missing %q:\n%s", want, data)
+		}
+	}
+}
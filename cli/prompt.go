@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptModel asks for a single masked value outside the wizard
+type promptModel struct {
+	label     string
+	input     textinput.Model
+	confirmed bool
+}
+
+func newPromptModel(label string) promptModel {
+	input := textinput.New()
+	input.Placeholder = label
+	input.CharLimit = 5000
+	input.Width = 50
+	input.EchoMode = textinput.EchoPassword
+	input.EchoCharacter = '*'
+	input.Focus()
+	return promptModel{label: label, input: input}
+}
+
+// Init implements tea.Model
+func (m promptModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update feeds keys to the input until Enter confirms or Ctrl+C cancels
+func (m promptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			m.confirmed = true
+			return m, tea.Quit
+		case "ctrl+r":
+			toggleReveal(&m.input)
+			return m, nil
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		}
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// View renders the prompt
+func (m promptModel) View() string {
+	if m.confirmed {
+		return ""
+	}
+	return fmt.Sprintf("Enter %s:\n\n%s\n\n(Press Enter to continue, Ctrl+R to show or hide, Ctrl+C to cancel)\n", m.label, m.input.View())
+}
+
+// promptSecret reads a value from the terminal without echoing it
+func promptSecret(label string) (string, error) {
+	final, err := tea.NewProgram(newPromptModel(label)).Run()
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", label, err)
+	}
+	result := final.(promptModel)
+	if !result.confirmed {
+		return "", fmt.Errorf("cancelled while reading %s", label)
+	}
+	value := result.input.Value()
+	secretValues.Add(value)
+	return value, nil
+}
+
+// pickModel asks to check items of a list outside the wizard
+type pickModel struct {
+	list      checklist
+	confirmed bool
+}
+
+// Init implements tea.Model
+func (m pickModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update toggles items until Enter confirms or Ctrl+C cancels
+func (m pickModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "enter":
+			m.confirmed = true
+			return m, tea.Quit
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+		}
+	}
+	m.list = m.list.Update(msg)
+	return m, nil
+}
+
+// View renders the checklist
+func (m pickModel) View() string {
+	if m.confirmed {
+		return ""
+	}
+	return m.list.View()
+}
+
+// promptPick shows items as a checklist, with the ones in checked already
+// ticked, and returns the indexes of the items picked
+func promptPick(title string, items []string, checked map[int]bool) ([]int, error) {
+	list := newChecklist(title, items, false)
+	for i := range checked {
+		list.checked[i] = true
+	}
+	final, err := tea.NewProgram(pickModel{list: list}).Run()
+	if err != nil {
+		return nil, fmt.Errorf("error reading selection: %v", err)
+	}
+	result := final.(pickModel)
+	if !result.confirmed {
+		return nil, fmt.Errorf("selection cancelled")
+	}
+	return result.list.Selected(), nil
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// minRedactLength skips values too short to redact without mangling
+// ordinary output
+const minRedactLength = 4
+
+// redactedValue replaces secret values in output
+const redactedValue = "********"
+
+// redactor remembers every secret value workflo handles and removes them
+// from text before it is printed
+type redactor struct {
+	mu     sync.Mutex
+	values []string
+}
+
+// secretValues is the redactor behind stdout and stderr
+var secretValues = &redactor{}
+
+// sensitiveJSONFields are the fields of a JSON credential, such as a
+// service account key, that are secret on their own. Fields like type or
+// project_id are left alone so ordinary output mentioning them stays readable.
+var sensitiveJSONFields = []string{"private_key", "private_key_id"}
+
+// Add registers values to redact. Each line of a multi-line value and the
+// sensitive fields of a JSON document are registered too, so partial output
+// of a service account key is still caught. Values stay registered for the
+// rest of the run so errors reported after an upload are still redacted.
+func (r *redactor) Add(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range values {
+		parts := append([]string{value}, strings.Split(value, "\n")...)
+		var doc map[string]interface{}
+		if json.Unmarshal([]byte(value), &doc) == nil {
+			for _, key := range sensitiveJSONFields {
+				if s, ok := doc[key].(string); ok {
+					parts = append(parts, s)
+					parts = append(parts, strings.Split(s, "\n")...)
+				}
+			}
+		}
+		for _, v := range parts {
+			if v = strings.TrimSpace(v); len(v) >= minRedactLength {
+				r.values = append(r.values, v)
+			}
+		}
+	}
+	// Longest first so a value containing another is replaced whole
+	sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+// Redact replaces every registered value in s
+func (r *redactor) Redact(s string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, value := range r.values {
+		s = strings.ReplaceAll(s, value, redactedValue)
+	}
+	return s
+}
+
+// redactError returns err with registered values removed from its message
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return errors.New(secretValues.Redact(err.Error()))
+}
+
+// redactingWriter redacts registered values from everything written to it
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (rw redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, secretValues.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// stdout and stderr are used for all of workflo's own output
+var (
+	stdout io.Writer = redactingWriter{os.Stdout}
+	stderr io.Writer = redactingWriter{os.Stderr}
+)
+
+// wipeSecrets drops the values from a map of secrets once they have been
+// uploaded, so nothing later in the run can reach them through the map. Go
+// strings are immutable, so this does not scrub memory: the bytes remain
+// until the garbage collector reuses them, and the redactor keeps its own
+// copy for masking output.
+func wipeSecrets(secrets map[string]string) {
+	for name := range secrets {
+		secrets[name] = ""
+		delete(secrets, name)
+	}
+}
+
+// toggleReveal switches a masked input between hidden and visible
+func toggleReveal(input *textinput.Model) {
+	if input.EchoMode == textinput.EchoPassword {
+		input.EchoMode = textinput.EchoNormal
+	} else {
+		input.EchoMode = textinput.EchoPassword
+	}
+}
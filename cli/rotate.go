@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"workflo/githubactions"
+)
+
+// runSecretsRotate replaces existing secrets with new values, either the
+// named secrets or every secret of a cloud provider's access keys, and
+// records the rotation dates so `secrets list` can flag stale secrets
+func runSecretsRotate(args []string) error {
+	var opts secretsFlags
+	fs := newSecretsFlagSet("rotate", &opts)
+	providerName := fs.String("provider", "", "rotate the access keys of a cloud provider, e.g. AWS")
+	prefix := fs.String("prefix", "", "prefix of the provider's secret names, e.g. the workflow name")
+	suffix := fs.String("suffix", "", "suffix of the provider's secret names, e.g. the environment name")
+	environment := fs.String("environment", "", "rotate secrets of this GitHub environment instead of the repository")
+	var scopes scopeFlag
+	registerScopeFlag(fs, &scopes)
+	value := fs.String("value", "", "new secret value (visible in shell history, prefer --stdin or the prompt)")
+	stdin := fs.Bool("stdin", false, "read the new secret value from standard input")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var provider githubactions.CloudProvider
+	var cfg githubactions.CloudConfig
+	var fields []githubactions.CredentialField
+	switch {
+	case *providerName != "" && len(names) > 0:
+		return fmt.Errorf("give either secret names or --provider, not both")
+	case *providerName != "":
+		if provider = lookupProvider(*providerName); provider == nil {
+			return fmt.Errorf("unknown provider %q, expected one of %s", *providerName, strings.Join(providerNames(), ", "))
+		}
+		if *value != "" || *stdin {
+			return fmt.Errorf("--value and --stdin rotate a single named secret")
+		}
+		cfg = githubactions.CloudConfig{
+			Prefix: githubactions.SanitizeSecretPrefix(*prefix),
+			Suffix: githubactions.SanitizeSecretPrefix(*suffix),
+			Values: make(map[string]string),
+		}
+		fields = githubactions.RotationFields(provider)
+		if len(fields) == 0 {
+			return fmt.Errorf("%s has no access keys to rotate", provider.Name())
+		}
+		names = sortedKeys(provider.RotatedSecrets(cfg))
+	case len(names) == 0:
+		return fmt.Errorf("at least one secret name or --provider is required")
+	case (*value != "" || *stdin) && len(names) > 1:
+		return fmt.Errorf("--value and --stdin rotate a single secret")
+	}
+	if err := validateSecretNames(names); err != nil {
+		return err
+	}
+	// GitHub stores secret names in upper case
+	for i, name := range names {
+		names[i] = strings.ToUpper(name)
+	}
+	if *environment != "" && len(scopes) > 0 {
+		return fmt.Errorf("--environment cannot be combined with --scope")
+	}
+
+	ctx := context.Background()
+	client, owner, repo, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	stores := scopes.stores(client, owner, repo)
+	if *environment != "" {
+		store, err := newEnvSecretStore(ctx, client, owner, repo, *environment)
+		if err != nil {
+			return err
+		}
+		stores = []secretStore{store}
+	}
+
+	// Rotation replaces secrets, so check they exist before asking for values
+	for _, store := range stores {
+		existing, err := store.Names(ctx)
+		if err != nil {
+			return fmt.Errorf("error listing secrets in %s: %v", store, err)
+		}
+		var missing []string
+		for _, name := range names {
+			if !existing[name] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%s not found in %s; create new secrets with `workflo secrets set`", strings.Join(missing, ", "), store)
+		}
+	}
+
+	secrets := make(map[string]string)
+	defer wipeSecrets(secrets)
+	if provider != nil {
+		for _, field := range fields {
+			v, err := promptSecret("the new " + field.Label)
+			if err != nil {
+				return err
+			}
+			if field.Validate != nil {
+				if err := field.Validate(v); err != nil {
+					return fmt.Errorf("invalid %s: %v", field.Label, err)
+				}
+			}
+			cfg.Values[field.Key] = v
+		}
+		for name, v := range provider.RotatedSecrets(cfg) {
+			secrets[name] = v
+		}
+		wipeSecrets(cfg.Values)
+	} else if err := readSecretValues(secrets, names, *value, *stdin); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, store := range stores {
+		if len(stores) > 1 {
+			fmt.Fprintf(stdout, "%s:\n", store)
+		}
+		report, err := uploadSecrets(ctx, store, secrets)
+		if err == nil {
+			err = printReport(report, nil)
+			recordRotations(store.String(), report)
+		}
+		if err != nil {
+			if len(stores) == 1 {
+				return err
+			}
+			fmt.Fprintf(stdout, "%v\n", err)
+			failed = append(failed, store.String())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("error rotating secrets in %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// lookupProvider finds a provider by name, ignoring case
+func lookupProvider(name string) githubactions.CloudProvider {
+	for _, provider := range githubactions.CloudProviders {
+		if strings.EqualFold(provider.Name(), name) {
+			return provider
+		}
+	}
+	return nil
+}
+
+// providerNames lists the registered providers
+func providerNames() []string {
+	var names []string
+	for _, provider := range githubactions.CloudProviders {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// recordRotations stores the rotation date of every secret the report
+// shows as uploaded. Failing to record is only a warning, as the secrets
+// were rotated.
+func recordRotations(store string, report secretReport) {
+	md, err := loadSecretMetadata()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: rotation dates not recorded: %v\n", err)
+		return
+	}
+	now := time.Now()
+	recorded := 0
+	for _, result := range report {
+		if result.Err == nil {
+			md.recordRotation(store, result.Name, now)
+			recorded++
+		}
+	}
+	if recorded == 0 {
+		return
+	}
+	path, err := md.save()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: rotation dates not recorded: %v\n", err)
+		return
+	}
+	fmt.Fprintf(stdout, "Recorded the rotation of %d secret(s) in %s.\n", recorded, path)
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// Secret scopes, each a separate set of repository secrets read by a
+// different feature. Dependabot runs cannot read Actions secrets.
+const (
+	scopeActions    = "actions"
+	scopeDependabot = "dependabot"
+	scopeCodespaces = "codespaces"
+)
+
+var secretScopes = []string{scopeActions, scopeDependabot, scopeCodespaces}
+
+// scopeFlag collects --scope values, given comma separated or repeated
+type scopeFlag []string
+
+func (s *scopeFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *scopeFlag) Set(value string) error {
+	for _, scope := range strings.Split(value, ",") {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		known := false
+		for _, valid := range secretScopes {
+			known = known || scope == valid
+		}
+		if !known {
+			return fmt.Errorf("unknown scope %q, expected %s", scope, strings.Join(secretScopes, ", "))
+		}
+		if !strings.Contains(","+s.String()+",", ","+scope+",") {
+			*s = append(*s, scope)
+		}
+	}
+	return nil
+}
+
+// registerScopeFlag adds --scope to fs
+func registerScopeFlag(fs *flag.FlagSet, scopes *scopeFlag) {
+	fs.Var(scopes, "scope", "secret scopes to use, comma separated: actions, dependabot, codespaces (default actions)")
+}
+
+// stores returns the repository's secret store in every scope, Actions
+// when no scope was given
+func (s scopeFlag) stores(client *github.Client, owner, repo string) []secretStore {
+	scopes := []string(s)
+	if len(scopes) == 0 {
+		scopes = []string{scopeActions}
+	}
+	stores := make([]secretStore, len(scopes))
+	for i, scope := range scopes {
+		if scope == scopeActions {
+			stores[i] = repoSecretStore{client: client, owner: owner, repo: repo}
+		} else {
+			stores[i] = scopedSecretStore{client: client, owner: owner, repo: repo, scope: scope}
+		}
+	}
+	return stores
+}
+
+// scopedSecretStore holds the repository secrets of Dependabot or
+// Codespaces, which the go-github version in use does not cover. Their API
+// mirrors Actions secrets under a different path.
+type scopedSecretStore struct {
+	client      *github.Client
+	owner, repo string
+	scope       string
+}
+
+func (s scopedSecretStore) path(suffix string) string {
+	return fmt.Sprintf("repos/%s/%s/%s/secrets%s", s.owner, s.repo, s.scope, suffix)
+}
+
+func (s scopedSecretStore) PublicKey(ctx context.Context) (*github.PublicKey, *github.Response, error) {
+	req, err := s.client.NewRequest(http.MethodGet, s.path("/public-key"), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := new(github.PublicKey)
+	resp, err := s.client.Do(ctx, req, key)
+	return key, resp, err
+}
+
+func (s scopedSecretStore) Put(ctx context.Context, secret *github.EncryptedSecret) (*github.Response, error) {
+	req, err := s.client.NewRequest(http.MethodPut, s.path("/"+url.PathEscape(secret.Name)), secret)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
+
+func (s scopedSecretStore) Delete(ctx context.Context, name string) (*github.Response, error) {
+	req, err := s.client.NewRequest(http.MethodDelete, s.path("/"+url.PathEscape(name)), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, req, nil)
+}
+
+func (s scopedSecretStore) List(ctx context.Context, opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+	req, err := s.client.NewRequest(http.MethodGet, s.path(fmt.Sprintf("?per_page=%d&page=%d", opts.PerPage, opts.Page)), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	secrets := new(github.Secrets)
+	resp, err := s.client.Do(ctx, req, secrets)
+	return secrets, resp, err
+}
+
+func (s scopedSecretStore) Names(ctx context.Context) (map[string]bool, error) {
+	return storeSecretNames(ctx, s)
+}
+
+func (s scopedSecretStore) String() string { return s.owner + "/" + s.repo + " (" + s.scope + ")" }
+
+// uploadToStores uploads secrets to every store, printing each report under
+// the store's name when there are several
+func uploadToStores(ctx context.Context, stores []secretStore, secrets map[string]string) error {
+	if len(stores) == 1 {
+		return printReport(uploadSecrets(ctx, stores[0], secrets))
+	}
+	// Keep going after a failing store so the report covers all of them
+	var failed []string
+	for _, store := range stores {
+		fmt.Fprintf(stdout, "%s:\n", store)
+		if err := printReport(uploadSecrets(ctx, store, secrets)); err != nil {
+			fmt.Fprintf(stdout, "%v\n", err)
+			failed = append(failed, store.String())
+		}
+		fmt.Fprintln(stdout)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("error uploading to %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,482 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"workflo/githubactions"
+
+	"github.com/google/go-github/v41/github"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const secretsUsage = `Usage: workflo secrets <command> [flags]
+
+Commands:
+  list                      List the repository's secret names and when they were last updated or
+                            rotated, flagging any older than --max-age (default 90d)
+  set NAME...               Create or update secrets, reading values from --value, --stdin or a prompt;
+                            a value of exec:COMMAND, file:PATH or env:VAR is read from there
+                            (commands get no terminal, so they must not prompt)
+  delete NAME...            Delete secrets
+  import FILE               Upload selected entries of a dotenv file, optionally with --prefix
+  sync [NAME...] --repo R...
+                            Copy secrets to every --repo, reading values from environment
+                            variables of the same name, the vault or a prompt; without
+                            names, every value in the vault is copied
+  vault <command>           Store named values in a local passphrase-encrypted vault
+  org <command>             Manage organization secrets and the repositories that can read them
+  rotate NAME...            Replace existing secrets with new values and record the rotation date;
+                            --provider AWS rotates a cloud provider's credentials
+  audit                     Compare the secrets referenced in .github/workflows (or --dir) with
+                            the repository, environment and organization secrets, and exit
+                            non-zero when any are missing, unused or shadowed
+
+Every command takes --repo owner/name, --token, --github-url and --api-url. Without --repo,
+the repository is read from the git remote named origin. list, set, delete, import, sync and
+rotate also take --scope actions,dependabot,codespaces to work on Dependabot or Codespaces secrets,
+or on several scopes at once.
+`
+
+// repoFlag collects repeated --repo owner/name flags
+type repoFlag []string
+
+func (r *repoFlag) String() string { return strings.Join(*r, ",") }
+
+func (r *repoFlag) Set(value string) error {
+	if _, _, err := splitRepo(value); err != nil {
+		return err
+	}
+	*r = append(*r, value)
+	return nil
+}
+
+// splitRepo splits an owner/name repository reference
+func splitRepo(repo string) (string, string, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("invalid repository %q, expected owner/name", repo)
+	}
+	return owner, name, nil
+}
+
+// secretsFlags are the flags shared by every secrets command
+type secretsFlags struct {
+	repos  repoFlag
+	token  string
+	server githubServer
+}
+
+func newSecretsFlagSet(name string, opts *secretsFlags) *flag.FlagSet {
+	fs := flag.NewFlagSet("secrets "+name, flag.ContinueOnError)
+	fs.Var(&opts.repos, "repo", "repository as owner/name")
+	fs.StringVar(&opts.token, "token", "", "GitHub token with access to the repository's secrets (defaults to $GITHUB_TOKEN or $GH_TOKEN, $GH_ENTERPRISE_TOKEN on Enterprise Server, then the gh CLI config or a git credential helper)")
+	opts.server.registerFlags(fs)
+	return fs
+}
+
+// parseInterspersed parses fs allowing flags to follow positional arguments,
+// e.g. `set NAME --repo owner/name`, and returns the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// client builds the GitHub client and checks a single repository was given.
+// Without --repo the repository, and its host, come from the origin remote.
+func (opts secretsFlags) client(ctx context.Context) (*github.Client, string, string, error) {
+	server := opts.server
+	if len(opts.repos) == 0 {
+		remotes, err := readGitRemotes(".")
+		if err != nil || len(remotes) == 0 {
+			return nil, "", "", fmt.Errorf("--repo owner/name is required outside a git repository with a GitHub remote")
+		}
+		opts.repos = repoFlag{remotes[0].Owner + "/" + remotes[0].Repo}
+		var warning string
+		server, warning = serverForRemote(server, remotes[0].Host)
+		if warning != "" {
+			fmt.Fprintf(stderr, "Warning: %s.\n", warning)
+		}
+	}
+	if len(opts.repos) != 1 {
+		return nil, "", "", fmt.Errorf("exactly one --repo owner/name is required")
+	}
+	owner, repo, _ := splitRepo(opts.repos[0])
+	client, err := newGitHubClient(ctx, opts.discoverToken(server), server)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return client, owner, repo, nil
+}
+
+// discoverToken returns --token, or a token discovered for the server's
+// host. The source is reported on stderr; the token itself is never printed.
+func (opts secretsFlags) discoverToken(server githubServer) string {
+	if opts.token != "" {
+		secretValues.Add(opts.token)
+		return opts.token
+	}
+	token, source := discoverToken(server.Host())
+	if token != "" {
+		secretValues.Add(token)
+		fmt.Fprintf(stderr, "Using the GitHub token from %s.\n", source)
+	}
+	return token
+}
+
+// runSecrets manages repository secrets outside the wizard
+func runSecrets(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, secretsUsage)
+		return fmt.Errorf("missing secrets command")
+	}
+
+	switch args[0] {
+	case "list":
+		return runSecretsList(args[1:])
+	case "set":
+		return runSecretsSet(args[1:])
+	case "delete":
+		return runSecretsDelete(args[1:])
+	case "sync":
+		return runSecretsSync(args[1:])
+	case "import":
+		return runSecretsImport(args[1:])
+	case "rotate":
+		return runSecretsRotate(args[1:])
+	case "vault":
+		return runSecretsVault(args[1:])
+	case "org":
+		return runSecretsOrg(args[1:])
+	case "audit":
+		return runSecretsAudit(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, secretsUsage)
+		return nil
+	default:
+		fmt.Fprint(stderr, secretsUsage)
+		return fmt.Errorf("unknown secrets command %q", args[0])
+	}
+}
+
+// runSecretsList prints the name and update time of every secret, flagging
+// those not rotated within the maximum age. Values cannot be read back from
+// GitHub and are never shown.
+func runSecretsList(args []string) error {
+	var opts secretsFlags
+	fs := newSecretsFlagSet("list", &opts)
+	var scopes scopeFlag
+	registerScopeFlag(fs, &scopes)
+	maxAgeFlag := fs.String("max-age", "", "flag secrets not rotated or updated for this long, e.g. 90d (defaults to max_secret_age in the config file, then 90d)")
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	maxAge, err := maxSecretAge(*maxAgeFlag)
+	if err != nil {
+		return err
+	}
+	md, err := loadSecretMetadata()
+	if err != nil {
+		fmt.Fprintf(stderr, "Warning: %v\n", err)
+	}
+
+	ctx := context.Background()
+	client, owner, repo, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	stores := scopes.stores(client, owner, repo)
+	stale := 0
+	for i, store := range stores {
+		if len(stores) > 1 {
+			if i > 0 {
+				fmt.Fprintln(stdout)
+			}
+			fmt.Fprintf(stdout, "%s:\n", store)
+		}
+		n, err := printSecretAges(ctx, store, md, maxAge)
+		if err != nil {
+			return err
+		}
+		stale += n
+	}
+	if stale > 0 {
+		fmt.Fprintf(stdout, "\n%d secret(s) older than %s; replace them with `workflo secrets rotate NAME`.\n", stale, formatAge(maxAge))
+	}
+	return nil
+}
+
+// printSecretAges prints the secrets of a store with their update and
+// rotation dates, returning how many are older than maxAge
+func printSecretAges(ctx context.Context, store secretStore, md secretMetadata, maxAge time.Duration) (int, error) {
+	secrets, err := listSecrets(ctx, store)
+	if err != nil {
+		return 0, fmt.Errorf("error listing secrets in %s: %v", store, err)
+	}
+	if len(secrets) == 0 {
+		fmt.Fprintf(stdout, "No secrets found in %s.\n", store)
+		return 0, nil
+	}
+
+	stale := 0
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tUPDATED\tROTATED\tSTATUS")
+	for _, secret := range secrets {
+		// Without a recorded rotation, the last update is the best guess
+		changed, rotatedLabel := secret.UpdatedAt.Time, "-"
+		if rotated, ok := md.rotated(store.String(), secret.Name); ok {
+			changed, rotatedLabel = rotated, rotated.Local().Format("2006-01-02 15:04:05")
+		}
+		status := "ok"
+		if age := time.Since(changed); age > maxAge {
+			status = fmt.Sprintf("stale (%d days)", int(age.Hours()/24))
+			stale++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", secret.Name, secret.UpdatedAt.Format("2006-01-02 15:04:05"), rotatedLabel, status)
+	}
+	return stale, w.Flush()
+}
+
+// runSecretsSet creates or updates secrets. A single secret's value may come
+// from --value or --stdin, otherwise each value is prompted for. Values may
+// be exec:, file: or env: references, read just before the upload.
+func runSecretsSet(args []string) error {
+	var opts secretsFlags
+	fs := newSecretsFlagSet("set", &opts)
+	var scopes scopeFlag
+	registerScopeFlag(fs, &scopes)
+	value := fs.String("value", "", "secret value (visible in shell history, prefer --stdin or the prompt)")
+	stdin := fs.Bool("stdin", false, "read the secret value from standard input")
+	literal := fs.Bool("literal", false, "upload values starting with exec:, file: or env: as they are instead of resolving them")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("at least one secret name is required")
+	}
+	if err := validateSecretNames(names); err != nil {
+		return err
+	}
+	if (*value != "" || *stdin) && len(names) > 1 {
+		return fmt.Errorf("--value and --stdin set a single secret")
+	}
+
+	secrets := make(map[string]string)
+	defer wipeSecrets(secrets)
+	if err := readSecretValues(secrets, names, *value, *stdin); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if !*literal {
+		resolved, err := resolveValueRefs(ctx, secrets)
+		if err != nil {
+			return err
+		}
+		defer wipeSecrets(resolved)
+		secrets = resolved
+	}
+	client, owner, repo, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	return uploadToStores(ctx, scopes.stores(client, owner, repo), secrets)
+}
+
+// readSecretValues fills secrets with a value for every name, taken from
+// value or stdin for a single secret and prompted for otherwise
+func readSecretValues(secrets map[string]string, names []string, value string, stdin bool) error {
+	switch {
+	case value != "":
+		secrets[names[0]] = value
+	case stdin:
+		v, err := readStdinValue(os.Stdin)
+		if err != nil {
+			return err
+		}
+		secrets[names[0]] = v
+	default:
+		for _, name := range names {
+			v, err := promptSecret(name)
+			if err != nil {
+				return err
+			}
+			secrets[name] = v
+		}
+	}
+	return nil
+}
+
+// validateSecretNames rejects names GitHub would refuse, before any value
+// is prompted for
+func validateSecretNames(names []string) error {
+	for _, name := range names {
+		if err := githubactions.ValidateSecretName(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readStdinValue reads a secret from r, dropping a single trailing newline
+// so `echo value |` works as expected
+func readStdinValue(r io.Reader) (string, error) {
+	data, err := io.ReadAll(bufio.NewReader(r))
+	if err != nil {
+		return "", fmt.Errorf("error reading secret from stdin: %v", err)
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	if value == "" {
+		return "", fmt.Errorf("no secret value on stdin")
+	}
+	return value, nil
+}
+
+// runSecretsDelete deletes the named secrets
+func runSecretsDelete(args []string) error {
+	var opts secretsFlags
+	fs := newSecretsFlagSet("delete", &opts)
+	var scopes scopeFlag
+	registerScopeFlag(fs, &scopes)
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("at least one secret name is required")
+	}
+	if err := validateSecretNames(names); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, owner, repo, err := opts.client(ctx)
+	if err != nil {
+		return err
+	}
+	for _, store := range scopes.stores(client, owner, repo) {
+		for _, name := range names {
+			if _, err := store.Delete(ctx, name); err != nil {
+				return fmt.Errorf("error deleting secret %s from %s: %v", name, store, err)
+			}
+			fmt.Fprintf(stdout, "Deleted %s from %s.\n", name, store)
+		}
+	}
+	return nil
+}
+
+// runSecretsSync writes the same secrets to every --repo. Values are read
+// from environment variables named after the secrets, the vault, or prompted
+// for. Without names, every value in the vault is written. The vault is only
+// unlocked when it has values to offer.
+func runSecretsSync(args []string) error {
+	var opts secretsFlags
+	fs := newSecretsFlagSet("sync", &opts)
+	var scopes scopeFlag
+	registerScopeFlag(fs, &scopes)
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(opts.repos) == 0 {
+		return fmt.Errorf("at least one --repo owner/name is required")
+	}
+	if len(names) == 0 && !vaultExists() {
+		return fmt.Errorf("at least one secret name is required, or store values with `workflo secrets vault set`")
+	}
+	if err := validateSecretNames(names); err != nil {
+		return err
+	}
+
+	secrets := make(map[string]string)
+	defer wipeSecrets(secrets)
+	var missing []string
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			secrets[name] = v
+		} else {
+			missing = append(missing, name)
+		}
+	}
+
+	if (len(names) == 0 || len(missing) > 0) && vaultExists() {
+		vault, _, err := unlockVault()
+		if err != nil {
+			return err
+		}
+		defer wipeSecrets(vault)
+		// Without names, everything in the vault is synced
+		if len(names) == 0 {
+			missing = sortedKeys(vault)
+		}
+		var stillMissing []string
+		for _, name := range missing {
+			if v, ok := vault[name]; ok {
+				secrets[name] = v
+			} else {
+				stillMissing = append(stillMissing, name)
+			}
+		}
+		missing = stillMissing
+	}
+
+	for _, name := range missing {
+		v, err := promptSecret(name)
+		if err != nil {
+			return err
+		}
+		secrets[name] = v
+	}
+
+	ctx := context.Background()
+	client, err := newGitHubClient(ctx, opts.discoverToken(opts.server), opts.server)
+	if err != nil {
+		return err
+	}
+	var stores []secretStore
+	for _, target := range opts.repos {
+		owner, repo, _ := splitRepo(target)
+		stores = append(stores, scopes.stores(client, owner, repo)...)
+	}
+	return uploadToStores(ctx, stores, secrets)
+}
+
+// Function to encrypt the secret value using the repository's public key
+func encryptSecret(secretValue []byte, publicKey string) (string, error) {
+	// Decode the public key from Base64
+	keyBytes, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil {
+		return "", fmt.Errorf("error decoding public key: %v", err)
+	}
+
+	var publicKeyBytes [32]byte
+	copy(publicKeyBytes[:], keyBytes)
+
+	// Encrypt the secret using sealed box
+	encryptedBytes, err := box.SealAnonymous(nil, secretValue, &publicKeyBytes, rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("error encrypting secret: %v", err)
+	}
+
+	// Return the encrypted secret in Base64 encoding
+	return base64.StdEncoding.EncodeToString(encryptedBytes), nil
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// fakeSecretsAPI serves the repository secrets endpoints used by
+// `secrets set`, listing the existing secrets given and recording every
+// uploaded secret
+type fakeSecretsAPI struct {
+	publicKey  *[32]byte
+	privateKey *[32]byte
+
+	mu       sync.Mutex
+	uploaded map[string]map[string]string
+}
+
+func newFakeSecretsAPI(t *testing.T, existing ...string) (*httptest.Server, *fakeSecretsAPI) {
+	t.Helper()
+	publicKey, privateKey, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &fakeSecretsAPI{publicKey: publicKey, privateKey: privateKey, uploaded: map[string]map[string]string{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/octo/app/actions/secrets/public-key", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"key_id": "key-1",
+			"key":    base64.StdEncoding.EncodeToString(publicKey[:]),
+		})
+	})
+	mux.HandleFunc("GET /repos/octo/app/actions/secrets", func(w http.ResponseWriter, r *http.Request) {
+		secrets := []map[string]string{}
+		for _, name := range existing {
+			secrets = append(secrets, map[string]string{"name": name})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"total_count": len(secrets), "secrets": secrets})
+	})
+	mux.HandleFunc("PUT /repos/octo/app/actions/secrets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		api.mu.Lock()
+		api.uploaded[r.PathValue("name")] = body
+		api.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, api
+}
+
+func TestSecretsSetUploadsEncryptedValue(t *testing.T) {
+	t.Setenv(configEnv, t.TempDir()+"/config.yml")
+	server, api := newFakeSecretsAPI(t)
+
+	err := runSecrets([]string{"set", "API_KEY", "--repo", "octo/app", "--token", "test-token",
+		"--api-url", server.URL, "--value", "s3cret-value"})
+	if err != nil {
+		t.Fatalf("secrets set: %v", err)
+	}
+
+	body, ok := api.uploaded["API_KEY"]
+	if !ok {
+		t.Fatalf("API_KEY was not uploaded, got %v", api.uploaded)
+	}
+	if body["key_id"] != "key-1" {
+		t.Errorf("key_id = %q, want key-1", body["key_id"])
+	}
+	sealed, err := base64.StdEncoding.DecodeString(body["encrypted_value"])
+	if err != nil {
+		t.Fatalf("encrypted_value is not base64: %v", err)
+	}
+	plain, ok := box.OpenAnonymous(nil, sealed, api.publicKey, api.privateKey)
+	if !ok {
+		t.Fatal("encrypted_value does not open with the repository key")
+	}
+	if string(plain) != "s3cret-value" {
+		t.Errorf("uploaded value = %q, want s3cret-value", plain)
+	}
+}
+
+func TestSecretsSetReportsAPIErrors(t *testing.T) {
+	t.Setenv(configEnv, t.TempDir()+"/config.yml")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := runSecrets([]string{"set", "API_KEY", "--repo", "octo/app", "--token", "test-token",
+		"--api-url", server.URL, "--value", "s3cret-value"})
+	if err == nil {
+		t.Fatal("secrets set succeeded against a missing repository")
+	}
+}
+
+func TestUploadSecretsMatchesExistingNamesIgnoringCase(t *testing.T) {
+	server, _ := newFakeSecretsAPI(t, "API_KEY")
+	client, err := newGitHubClient(context.Background(), "test-token", githubServer{APIURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := repoSecretStore{client: client, owner: "octo", repo: "app"}
+
+	report, err := uploadSecrets(context.Background(), store, map[string]string{"api_key": "s3cret-value"})
+	if err != nil {
+		t.Fatalf("uploadSecrets: %v", err)
+	}
+	if len(report) != 1 || !report[0].Existed || report[0].Action != "updated" {
+		t.Errorf("report = %+v, want api_key updated over the existing API_KEY", report)
+	}
+	if created := report.Created(); len(created) != 0 {
+		t.Errorf("Created() = %v, want none so rollback keeps API_KEY", created)
+	}
+}
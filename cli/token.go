@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// credentialHelperTimeout bounds `git credential fill`, which may otherwise
+// wait on a helper that prompts
+const credentialHelperTimeout = 5 * time.Second
+
+// discoverToken looks for a GitHub token for host without prompting: first
+// the environment, then the gh CLI's hosts.yml, then git's credential
+// helpers. It returns the token and a description of where it was found, or
+// empty strings when there is none.
+func discoverToken(host string) (token, source string) {
+	if host == "" {
+		host = defaultGitHubHost
+	}
+
+	// GITHUB_TOKEN and GH_TOKEN are github.com tokens, like in the gh CLI,
+	// so they are never sent to another host
+	envVars := []string{"GITHUB_TOKEN", "GH_TOKEN"}
+	if host != defaultGitHubHost {
+		envVars = []string{"GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN"}
+	}
+	for _, name := range envVars {
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return value, "$" + name
+		}
+	}
+
+	if value, path := ghHostsToken(host); value != "" {
+		return value, "gh CLI config (" + path + ")"
+	}
+
+	if value := gitCredentialToken(host); value != "" {
+		return value, "git credential helper"
+	}
+	return "", ""
+}
+
+// ghConfigDir returns the directory holding the gh CLI's configuration
+func ghConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "GitHub CLI")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh")
+}
+
+// ghHost is a host entry of the gh CLI's hosts.yml
+type ghHost struct {
+	OAuthToken string `yaml:"oauth_token"`
+}
+
+// ghHostsToken reads the token gh stored for host. Recent gh versions keep
+// tokens in the system keyring instead, in which case none is found here.
+func ghHostsToken(host string) (token, path string) {
+	dir := ghConfigDir()
+	if dir == "" {
+		return "", ""
+	}
+	path = filepath.Join(dir, "hosts.yml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ""
+	}
+	var hosts map[string]ghHost
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return "", ""
+	}
+	return strings.TrimSpace(hosts[host].OAuthToken), path
+}
+
+// gitCredentialToken asks git's configured credential helpers for the
+// password stored for https://host, never letting git prompt the user
+func gitCredentialToken(host string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialHelperTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "password="); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
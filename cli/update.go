@@ -2,17 +2,16 @@ package cli
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/base64"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 	"workflo/githubactions"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/google/go-github/v41/github"
-	"golang.org/x/crypto/nacl/box"
-	"golang.org/x/oauth2"
 )
 
 // Update handles messages and updates the model state
@@ -58,64 +57,60 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.supportedCloud, cmd = m.supportedCloud.Update(msg)
 		return m.handleCloudProviderState(msg, cmd)
 
-	case stateConfigureAWSCredentials:
-		return m.handleConfigureAWSCredentialsState()
+	case stateCloudAuthMode:
+		m.cloudAuthMode, cmd = m.cloudAuthMode.Update(msg)
+		return m.handleCloudAuthModeState(msg, cmd)
 
-	case stateConfigureAWSAccessKeyID:
-		m.awsAccessKeyIDInput.Focus()
-		m.awsAccessKeyIDInput, cmd = m.awsAccessKeyIDInput.Update(msg)
-		return m.handleConfigureAWSAccessKeyIDState(msg, cmd)
+	case stateCloudCredentials:
+		m.credentialInput, cmd = m.credentialInput.Update(msg)
+		return m.handleCloudCredentialsState(msg, cmd)
 
-	case stateConfigureAWSSecretAccessKey:
-		m.awsSecretAccessKeyInput.Focus()
-		m.awsSecretAccessKeyInput, cmd = m.awsSecretAccessKeyInput.Update(msg)
-		return m.handleConfigureAWSSecretAccessKeyState(msg, cmd)
+	case stateVariableFields:
+		return m.handleVariableFieldsState(msg, cmd)
 
-	case stateConfigureAWSRegion:
-		m.awsRegionInput.Focus()
-		m.awsRegionInput, cmd = m.awsRegionInput.Update(msg)
-		return m.handleConfigureAWSRegionState(msg, cmd)
+	case stateDeployTarget:
+		m.deployTargets, cmd = m.deployTargets.Update(msg)
+		return m.handleDeployTargetState(msg, cmd)
 
-	case stateConfigureAzureCredentials:
-		return m.handleConfigureAzureCredentialsState()
+	case stateDockerOption:
+		m.dockerOption, cmd = m.dockerOption.Update(msg)
+		return m.handleDockerOptionState(msg, cmd)
 
-	case stateConfigureAzureClientID:
-		m.azureClientIDInput.Focus()
-		m.azureClientIDInput, cmd = m.azureClientIDInput.Update(msg)
-		return m.handleConfigureAzureClientIDState(msg, cmd)
+	case stateDockerRegistry:
+		m.dockerRegistries, cmd = m.dockerRegistries.Update(msg)
+		return m.handleDockerRegistryState(msg, cmd)
 
-	case stateConfigureAzureClientSecret:
-		m.azureClientSecretInput.Focus()
-		m.azureClientSecretInput, cmd = m.azureClientSecretInput.Update(msg)
-		return m.handleConfigureAzureClientSecretState(msg, cmd)
+	case stateDockerPlatforms:
+		return m.handleDockerPlatformsState(msg, cmd)
 
-	case stateConfigureAzureTenantID:
-		m.azureTenantIDInput.Focus()
-		m.azureTenantIDInput, cmd = m.azureTenantIDInput.Update(msg)
-		return m.handleConfigureAzureTenantIDState(msg, cmd)
+	case stateEnvironment:
+		m.environmentOption, cmd = m.environmentOption.Update(msg)
+		return m.handleEnvironmentState(msg, cmd)
 
-	case stateConfigureAzureSubscriptionID:
-		m.azureSubscriptionIDInput.Focus()
-		m.azureSubscriptionIDInput, cmd = m.azureSubscriptionIDInput.Update(msg)
-		return m.handleConfigureAzureSubscriptionIDState(msg, cmd)
+	case stateEnvironmentName, stateEnvironmentReviewers, stateEnvironmentWaitTimer:
+		m.environmentInput, cmd = m.environmentInput.Update(msg)
+		return m.handleEnvironmentInputState(msg, cmd)
 
-	case stateConfigureGCPCredentials:
-		return m.handleConfigureGCPCredentialsState()
+	case stateEnvironmentBranchPolicy:
+		m.branchPolicyOption, cmd = m.branchPolicyOption.Update(msg)
+		return m.handleEnvironmentBranchPolicyState(msg, cmd)
 
-	case stateConfigureGCPServiceAccountKey:
-		m.gcpServiceAccountKeyInput.Focus()
-		m.gcpServiceAccountKeyInput, cmd = m.gcpServiceAccountKeyInput.Update(msg)
-		return m.handleConfigureGCPServiceAccountKeyState(msg, cmd)
+	case stateAnotherAccount:
+		m.anotherAccountOption, cmd = m.anotherAccountOption.Update(msg)
+		return m.handleAnotherAccountState(msg, cmd)
 
-	case stateConfigureGCPProjectID:
-		m.gcpProjectIDInput.Focus()
-		m.gcpProjectIDInput, cmd = m.gcpProjectIDInput.Update(msg)
-		return m.handleConfigureGCPProjectIDState(msg, cmd)
+	case stateSecretNaming:
+		m.secretNamingOption, cmd = m.secretNamingOption.Update(msg)
+		return m.handleSecretNamingState(msg, cmd)
 
 	case stateConfigureSecretsOption:
 		m.configureSecretsOption, cmd = m.configureSecretsOption.Update(msg)
 		return m.handleConfigureSecretsOptionState(msg, cmd)
 
+	case stateGitRemote:
+		m.gitRemoteOption, cmd = m.gitRemoteOption.Update(msg)
+		return m.handleGitRemoteState(msg, cmd)
+
 	case stateGitHubUsername:
 		m.githubUsernameInput.Focus()
 		m.githubUsernameInput, cmd = m.githubUsernameInput.Update(msg)
@@ -131,8 +126,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.githubTokenInput, cmd = m.githubTokenInput.Update(msg)
 		return m.handleGitHubTokenState(msg, cmd)
 
+	case stateSecretPreview:
+		return m.handleSecretPreviewState(msg, cmd)
+
 	case stateComplete:
 		return m.handleCompleteState(msg, cmd)
+
+	case stateUploading:
+		return m.handleUploadingState(msg, cmd)
+
+	case stateUploadReport:
+		return m.handleUploadReportState(msg, cmd)
 	}
 	return m, cmd
 }
@@ -172,7 +176,7 @@ func (m model) handleCompleteState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd
 					{"cron": cronExpression},
 				}
 			default:
-				fmt.Println("Invalid schedule type selected.")
+				fmt.Fprintln(stdout, "Invalid schedule type selected.")
 				return m, tea.Quit
 			}
 
@@ -182,14 +186,28 @@ func (m model) handleCompleteState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd
 			}
 
 			// Generate steps for the job based on language and cloud provider
-			stepsYaml := githubactions.GetSkeleton(m.language, m.cloud, m.workflowNameUpper, m.awsRegion)
+			cloudConfig := m.cloudConfig()
+			// A deployment moves the provider's login into the separate deploy job
+			buildProvider := m.cloudProvider
+			if m.deployTarget != nil {
+				buildProvider = nil
+			}
+			stepsYaml := githubactions.GetSkeleton(m.language, buildProvider, cloudConfig)
+			if m.deployTarget != nil {
+				stepsYaml += githubactions.GetArtifactSkeleton(*m.deployTarget, cloudConfig)
+			}
 			steps := githubactions.ParseSteps(stepsYaml)
 
+			// Keyless authentication needs permission to request an OIDC token
+			if m.cloudProvider != nil && m.oidc {
+				workflow.SetPermissions(githubactions.OIDCPermissions())
+			}
+
 			// If git checkout is requested, add a step
 			if m.gitCheckout {
 				checkoutStep := githubactions.Step{
 					Name: "Checkout code",
-					Uses: "actions/checkout@v2",
+					Uses: githubactions.ActionRef("actions/checkout"),
 					With: map[string]string{
 						"ref": m.gitBranch,
 					},
@@ -197,7 +215,8 @@ func (m model) handleCompleteState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd
 				steps = append([]githubactions.Step{checkoutStep}, steps...)
 			}
 
-			// Create the job with runner and steps
+			// Create the job with runner and steps. The build job never runs in
+			// an environment, so protection rules never hold up pull request builds.
 			job := githubactions.Job{
 				RunsOn: m.runsOn,
 				Steps:  steps,
@@ -206,43 +225,52 @@ func (m model) handleCompleteState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd
 			// Add the job to the workflow
 			workflow.AddJob("build", job)
 
+			// Build and push the container image once the build succeeds
+			deployNeeds := []string{"build"}
+			if m.dockerRegistry != nil {
+				dockerYaml := githubactions.GetDockerSkeleton(*m.dockerRegistry, m.cloudProvider, cloudConfig, m.dockerfile, m.dockerPlatforms)
+				dockerJob := githubactions.Job{
+					Needs:       []string{"build"},
+					Environment: m.dockerEnvironment(),
+					RunsOn:      m.runsOn,
+					Steps:       githubactions.ParseSteps(dockerYaml),
+				}
+				workflow.AddJob("docker", dockerJob)
+				if m.dockerRegistry.Permissions != nil {
+					workflow.SetPermissions(m.dockerRegistry.Permissions)
+				}
+				deployNeeds = append(deployNeeds, "docker")
+			}
+
+			// Deploy after a successful build, only from the default branch.
+			// Every account gets its own deploy job and environment.
+			if m.deployTarget != nil {
+				for _, account := range m.allAccounts() {
+					deployYaml := githubactions.GetDeploySkeleton(m.cloudProvider, *m.deployTarget, m.cloudConfigFor(account))
+					workflow.AddJob(m.deployJobID(account), githubactions.Job{
+						Needs:       deployNeeds,
+						If:          githubactions.DefaultBranchCondition,
+						Environment: account.Environment.Name,
+						RunsOn:      m.runsOn,
+						Steps:       githubactions.ParseSteps(deployYaml),
+					})
+				}
+			}
+
 			// Generate the YAML file, handling any errors
 			// Changed the filename to "workflow.yml"
 			err := workflow.GenerateYAML("workflow.yml", true)
 			if err != nil {
-				fmt.Printf("Error generating workflow YAML: %v\n", err)
+				fmt.Fprintf(stdout, "Error generating workflow YAML: %v\n", err)
 			} else {
-				fmt.Println("Workflow YAML generated successfully.")
+				fmt.Fprintln(stdout, "Workflow YAML generated successfully.")
 			}
 
-			// Configure secrets if the user chose to
+			// Configure secrets if the user chose to. The upload runs as a
+			// command so its per-secret report renders in the TUI.
 			if m.configureSecrets {
-				// Initialize GitHub client
-				ctx := context.Background()
-				ts := oauth2.StaticTokenSource(
-					&oauth2.Token{AccessToken: m.githubToken},
-				)
-				tc := oauth2.NewClient(ctx, ts)
-				client := github.NewClient(tc)
-
-				// Configure secrets
-				var secrets map[string]string
-				if m.cloud == "AWS" {
-					secrets = m.awsSecrets
-				} else if m.cloud == "Azure" {
-					secrets = m.azureSecrets
-				} else if m.cloud == "GCP" {
-					secrets = m.gcpSecrets
-				} else {
-					secrets = make(map[string]string)
-				}
-
-				err = configureGitHubSecrets(ctx, client, m.githubUsername, m.githubRepoName, secrets)
-				if err != nil {
-					fmt.Printf("Error configuring GitHub secrets: %v\n", err)
-				} else {
-					fmt.Println("GitHub secrets configured successfully.")
-				}
+				m.state = stateUploading
+				return m, m.uploadCmd()
 			}
 
 			// Exit the program
@@ -252,57 +280,198 @@ func (m model) handleCompleteState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd
 	return m, cmd
 }
 
-// Function to configure GitHub secrets
-func configureGitHubSecrets(ctx context.Context, client *github.Client, owner, repo string, secrets map[string]string) error {
-	// Get public key for the repository
-	publicKey, _, err := client.Actions.GetRepoPublicKey(ctx, owner, repo)
-	if err != nil {
-		return fmt.Errorf("error getting public key: %v", err)
-	}
+// secretUpload is the outcome of uploading one account's secrets
+type secretUpload struct {
+	store  secretStore
+	report secretReport
+}
 
-	// Encrypt and upload each secret
-	for name, value := range secrets {
-		// Encrypt the secret value
-		encryptedValue, err := encryptSecret([]byte(value), *publicKey.Key)
+// uploadResultMsg carries the outcome of the wizard's secret upload
+type uploadResultMsg struct {
+	uploads  []secretUpload
+	failed   bool     // whether any account's environment, secrets or variables failed
+	messages []string // errors and notes shown above the report
+}
+
+// rollbackResultMsg carries the outcome of deleting the secrets a failed
+// upload created
+type rollbackResultMsg struct {
+	uploads []secretUpload
+}
+
+// uploadCmd configures the environments, secrets and variables of every
+// account in the background and reports back with an uploadResultMsg
+func (m model) uploadCmd() tea.Cmd {
+	return func() tea.Msg {
+		var result uploadResultMsg
+
+		// Initialize GitHub client
+		ctx := context.Background()
+		client, err := newGitHubClient(ctx, m.githubToken, m.githubServer)
 		if err != nil {
-			return fmt.Errorf("error encrypting secret %s: %v", name, err)
+			result.failed = true
+			result.messages = append(result.messages, fmt.Sprintf("Error creating GitHub client: %v", err))
+			return result
 		}
 
-		// Create the secret
-		secret := &github.EncryptedSecret{
-			Name:           name,
-			KeyID:          *publicKey.KeyID,
-			EncryptedValue: encryptedValue,
+		for _, account := range m.allAccounts() {
+			cloudConfig := m.cloudConfigFor(account)
+			result.messages = append(result.messages, m.uploadAccount(ctx, client, account, cloudConfig, &result)...)
 		}
+		return result
+	}
+}
 
-		_, err = client.Actions.CreateOrUpdateRepoSecret(ctx, owner, repo, secret)
-		if err != nil {
-			return fmt.Errorf("error creating/updating secret %s: %v", name, err)
+// uploadAccount configures one account's environment, secrets and variables,
+// adding its upload to result and returning the messages to show
+func (m model) uploadAccount(ctx context.Context, client *github.Client, account cloudAccount, cloudConfig githubactions.CloudConfig, result *uploadResultMsg) []string {
+	var messages []string
+
+	// Read any exec:, file: or env: references now, so values never sit in
+	// the wizard longer than needed
+	values, err := resolveCredentialValues(ctx, m.promptedFields(), account.Values)
+	if err != nil {
+		result.failed = true
+		return append(messages, fmt.Sprintf("Error reading credentials: %v", err))
+	}
+	defer wipeSecrets(values)
+	cloudConfig.Values = values
+
+	// Configure secrets
+	secrets := make(map[string]string)
+	if m.cloudProvider != nil {
+		secrets = m.cloudProvider.Secrets(cloudConfig)
+	}
+	defer wipeSecrets(secrets)
+
+	if account.Environment.Name != "" {
+		err = configureEnvironment(ctx, client, m.githubUsername, m.githubRepoName, account.Environment)
+	}
+	var store secretStore
+	if err == nil {
+		store, err = m.secretStoreFor(ctx, client, account)
+	}
+	if err == nil {
+		var report secretReport
+		report, err = uploadSecrets(ctx, store, secrets)
+		if err == nil {
+			result.uploads = append(result.uploads, secretUpload{store: store, report: report})
+			err = report.Err()
 		}
 	}
+	if err != nil {
+		result.failed = true
+		messages = append(messages, fmt.Sprintf("Error configuring GitHub secrets: %v", err))
+	} else {
+		messages = append(messages, fmt.Sprintf("GitHub secrets configured successfully in %s.", store))
+	}
 
-	return nil
+	// Store the non-sensitive values as variables
+	if m.cloudProvider != nil {
+		variables := m.cloudProvider.Variables(cloudConfig)
+		if len(variables) > 0 {
+			err = configureGitHubVariables(ctx, client, m.githubUsername, m.githubRepoName, m.secretEnvironment(account), variables)
+			// The workflow reads these through vars, so a missing variable
+			// breaks it just like a missing secret
+			if err != nil {
+				result.failed = true
+				messages = append(messages, fmt.Sprintf("Error configuring GitHub variables: %v", err))
+			} else {
+				messages = append(messages, "GitHub variables configured successfully.")
+			}
+		}
+	}
+	return messages
 }
 
-// Function to encrypt the secret value using the repository's public key
-func encryptSecret(secretValue []byte, publicKey string) (string, error) {
-	// Decode the public key from Base64
-	keyBytes, err := base64.StdEncoding.DecodeString(publicKey)
-	if err != nil {
-		return "", fmt.Errorf("error decoding public key: %v", err)
+// handleUploadingState waits for the upload to finish
+func (m model) handleUploadingState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case uploadResultMsg:
+		m.uploads = msg.uploads
+		m.uploadFailed = msg.failed
+		m.uploadMessages = msg.messages
+
+		// Drop the collected credentials and the token now they are no longer needed
+		for _, account := range m.allAccounts() {
+			for _, field := range m.promptedFields() {
+				if field.Sensitive {
+					delete(account.Values, field.Key)
+				}
+			}
+		}
+		m.githubToken = ""
+		m.state = stateUploadReport
+		return m, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
 	}
+	return m, cmd
+}
 
-	var publicKeyBytes [32]byte
-	copy(publicKeyBytes[:], keyBytes)
+// handleUploadReportState exits once the report has been read. After a
+// partial failure it offers to delete the secrets the run created.
+func (m model) handleUploadReportState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case rollbackResultMsg:
+		m.rollingBack = false
+		m.rollbackUploads = msg.uploads
+		return m, nil
+	case tea.KeyMsg:
+		if m.rollingBack {
+			if msg.String() == "ctrl+c" {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "d":
+			if m.canRollback() {
+				m.rollingBack = true
+				return m, rollbackCmd(m.uploads)
+			}
+		case "enter", "ctrl+c", "q":
+			return m, tea.Quit
+		}
+	}
+	return m, cmd
+}
 
-	// Encrypt the secret using sealed box
-	encryptedBytes, err := box.SealAnonymous(nil, secretValue, &publicKeyBytes, rand.Reader)
-	if err != nil {
-		return "", fmt.Errorf("error encrypting secret: %v", err)
+// canRollback reports whether a failed upload left created secrets behind
+func (m model) canRollback() bool {
+	return m.uploadFailed && m.rollbackUploads == nil && len(uploadedNames(m.uploads, secretReport.Created)) > 0
+}
+
+// uploadedNames lists the names pick selects from every upload, qualified
+// by the store when there is more than one
+func uploadedNames(uploads []secretUpload, pick func(secretReport) []string) []string {
+	var names []string
+	for _, upload := range uploads {
+		for _, name := range pick(upload.report) {
+			if len(uploads) > 1 {
+				name = fmt.Sprintf("%s in %s", name, upload.store)
+			}
+			names = append(names, name)
+		}
 	}
+	return names
+}
 
-	// Return the encrypted secret in Base64 encoding
-	return base64.StdEncoding.EncodeToString(encryptedBytes), nil
+// rollbackCmd deletes the secrets the uploads created in the background.
+// Overwritten secrets are left alone as their previous values are unknown.
+func rollbackCmd(uploads []secretUpload) tea.Cmd {
+	return func() tea.Msg {
+		var result rollbackResultMsg
+		for _, upload := range uploads {
+			if created := upload.report.Created(); len(created) > 0 {
+				report := deleteSecrets(context.Background(), upload.store, created)
+				result.uploads = append(result.uploads, secretUpload{store: upload.store, report: report})
+			}
+		}
+		return result
+	}
 }
 
 // Helper function to map cron frequency to cron expressions
@@ -328,7 +497,7 @@ func (m model) handleWorkflowNameState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea
 		switch msg.String() {
 		case "enter":
 			m.workflowName = m.textInput.Value()
-			m.workflowNameUpper = strings.ToUpper(m.workflowName)
+			m.workflowNameUpper = githubactions.SanitizeSecretPrefix(m.workflowName)
 			m.textInput.Reset()
 			m.state = stateRunner
 			return m, textinput.Blink
@@ -490,21 +659,19 @@ func (m model) handleCloudProviderState(msg tea.Msg, cmd tea.Cmd) (tea.Model, te
 		case "enter":
 			selectedCloud := m.supportedCloud.SelectedItem()
 			if selectedCloud != nil {
-				m.cloud = selectedCloud.FilterValue()
-				switch m.cloud {
-				case "AWS":
-					m.state = stateConfigureAWSCredentials
-				case "Azure":
-					m.state = stateConfigureAzureCredentials
-				case "GCP":
-					m.state = stateConfigureGCPCredentials
-				default:
-					m.state = stateConfigureSecretsOption
+				provider, ok := githubactions.LookupCloudProvider(selectedCloud.FilterValue())
+				if ok {
+					m.cloudProvider = provider
+					if provider.SupportsOIDC() {
+						m.state = stateCloudAuthMode
+						return m, cmd
+					}
+					m.oidc = false
+					return m.startCloudCredentials()
 				}
-			} else {
-				m.cloud = ""
-				m.state = stateConfigureSecretsOption
 			}
+			m.cloudProvider = nil
+			return m.startDocker()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
@@ -512,24 +679,17 @@ func (m model) handleCloudProviderState(msg tea.Msg, cmd tea.Cmd) (tea.Model, te
 	return m, cmd
 }
 
-// handleConfigureAWSCredentialsState transitions to AWS credential input states
-func (m model) handleConfigureAWSCredentialsState() (tea.Model, tea.Cmd) {
-	// Transition to the AWS Access Key ID input state
-	m.state = stateConfigureAWSAccessKeyID
-	return m, textinput.Blink
-}
-
-// AWS Credentials States
-func (m model) handleConfigureAWSAccessKeyIDState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// handleCloudAuthModeState processes the choice between access keys and OIDC
+func (m model) handleCloudAuthModeState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_AWS_ACCESS_KEY_ID", m.workflowNameUpper)
-			m.awsSecrets[secretKey] = m.awsAccessKeyIDInput.Value()
-			m.awsAccessKeyIDInput.Reset()
-			m.state = stateConfigureAWSSecretAccessKey
-			return m, textinput.Blink
+			selectedMode := m.cloudAuthMode.SelectedItem()
+			if selectedMode != nil {
+				m.oidc = selectedMode.FilterValue() == "OIDC (keyless)"
+				return m.startCloudCredentials()
+			}
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
@@ -537,59 +697,199 @@ func (m model) handleConfigureAWSAccessKeyIDState(msg tea.Msg, cmd tea.Cmd) (tea
 	return m, cmd
 }
 
-func (m model) handleConfigureAWSSecretAccessKeyState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// promptedFields lists every field the wizard asks for: the provider's
+// credentials, the deploy target's settings and the Docker registry's
+func (m model) promptedFields() []githubactions.CredentialField {
+	var fields []githubactions.CredentialField
+	if m.cloudProvider != nil {
+		fields = m.cloudProvider.Fields(m.oidc)
+	}
+	if m.deployTarget != nil {
+		fields = append(fields, m.deployTarget.Fields...)
+	}
+	if m.dockerRegistry != nil {
+		fields = append(fields, m.dockerRegistry.Fields...)
+	}
+	return fields
+}
+
+// acceptsValueRef reports whether a field may hold an exec:, file: or env:
+// reference. References are only resolved when uploading, so only the
+// provider's secrets and variables can use them; deploy and registry
+// settings are written into the workflow as entered. Inlined values can use
+// them when stored as a variable, which is chosen after the first account's
+// credentials, see inlineRefLabels.
+func (m model) acceptsValueRef(field githubactions.CredentialField) bool {
+	if m.cloudProvider == nil || field.Setting {
+		return false
+	}
+	if field.Inline && m.variablesAsked && !m.variables[field.Key] {
+		return false
+	}
+	for _, f := range m.cloudProvider.Fields(m.oidc) {
+		if f.Key == field.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// startCloudCredentials begins prompting for the selected provider's fields
+func (m model) startCloudCredentials() (tea.Model, tea.Cmd) {
+	m.credentialFields = m.cloudProvider.Fields(m.oidc)
+	m.credentialIndex = 0
+	m.credentialError = ""
+	if len(m.credentialFields) == 0 {
+		return m.finishCredentials()
+	}
+	m.credentialInput = newCredentialInput(m.credentialFields[0])
+	m.state = stateCloudCredentials
+	return m, textinput.Blink
+}
+
+// handleCloudCredentialsState validates and stores the current credential field
+func (m model) handleCloudCredentialsState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_AWS_SECRET_ACCESS_KEY", m.workflowNameUpper)
-			m.awsSecrets[secretKey] = m.awsSecretAccessKeyInput.Value()
-			m.awsSecretAccessKeyInput.Reset()
-			m.state = stateConfigureAWSRegion
-			return m, textinput.Blink
-		case "ctrl+c", "q":
+			field := m.credentialFields[m.credentialIndex]
+			value := strings.TrimSpace(m.credentialInput.Value())
+			// References are resolved and validated at upload time
+			switch {
+			case isValueRef(value) && !m.acceptsValueRef(field):
+				m.credentialError = "this value is written into the workflow, so enter it directly instead of as a reference"
+				return m, cmd
+			case isValueRef(value):
+				if err := checkValueRef(value); err != nil {
+					m.credentialError = err.Error()
+					return m, cmd
+				}
+			case field.Validate != nil:
+				if err := field.Validate(value); err != nil {
+					m.credentialError = err.Error()
+					return m, cmd
+				}
+			}
+			if field.Sensitive && !isValueRef(value) {
+				secretValues.Add(value)
+			}
+			m.credentialValues[field.Key] = value
+			m.credentialError = ""
+			m.credentialIndex++
+			if m.credentialIndex < len(m.credentialFields) {
+				m.credentialInput = newCredentialInput(m.credentialFields[m.credentialIndex])
+				return m, textinput.Blink
+			}
+			return m.finishCredentials()
+		case "ctrl+r":
+			if m.credentialFields[m.credentialIndex].Sensitive {
+				toggleReveal(&m.credentialInput)
+			}
+		case "ctrl+c":
 			return m, tea.Quit
 		}
 	}
 	return m, cmd
 }
 
-func (m model) handleConfigureAWSRegionState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// finishCredentials runs once the prompted fields are answered. It offers the
+// provider's deploy targets once, then the Docker job, then moves on to secrets.
+func (m model) finishCredentials() (tea.Model, tea.Cmd) {
+	if m.cloudProvider != nil && !m.variablesAsked {
+		m.variablesAsked = true
+		m.variableFields = variableCandidates(m.cloudProvider, m.oidc)
+		if len(m.variableFields) > 0 {
+			labels := make([]string, len(m.variableFields))
+			for i, field := range m.variableFields {
+				labels[i] = field.Label
+			}
+			m.variableOptions = newChecklist("Select the values to store as variables (readable in logs) instead of secrets:", labels, false)
+			for i, field := range m.variableFields {
+				m.variableOptions.checked[i] = field.Variable
+			}
+			m.state = stateVariableFields
+			return m, nil
+		}
+	}
+	// Platforms always deploy, in a deploy job like any other target
+	if target, ok := githubactions.PlatformDeployTarget(m.cloudProvider); ok && !m.deployTargetChosen {
+		m.deployTargetChosen = true
+		m.deployTarget = &target
+	}
+	if m.cloudProvider != nil && !m.deployTargetChosen && len(m.cloudProvider.DeployTargets()) > 0 {
+		m.deployTargets = newDeployTargetList(m.cloudProvider)
+		m.state = stateDeployTarget
+		return m, nil
+	}
+	if m.dockerRegistry != nil && m.dockerPlatforms == nil {
+		m.state = stateDockerPlatforms
+		return m, nil
+	}
+	return m.startDocker()
+}
+
+// handleVariableFieldsState processes which fields are stored as variables
+func (m model) handleVariableFieldsState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_AWS_REGION", m.workflowNameUpper)
-			m.awsSecrets[secretKey] = m.awsRegionInput.Value()
-			m.awsRegion = m.awsRegionInput.Value()
-			m.awsRegionInput.Reset()
-			m.state = stateConfigureSecretsOption
-			return m, textinput.Blink
+			m.variables = make(map[string]bool)
+			for _, i := range m.variableOptions.Selected() {
+				m.variables[m.variableFields[i].Key] = true
+			}
+			if labels := m.inlineRefLabels(); len(labels) > 0 {
+				m.variableError = fmt.Sprintf("%s holds a reference and would be written into the workflow, so store it as a variable", strings.Join(labels, ", "))
+				return m, nil
+			}
+			m.variableError = ""
+			return m.finishCredentials()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
 	}
+	m.variableOptions = m.variableOptions.Update(msg)
 	return m, cmd
 }
 
-// handleConfigureAzureCredentialsState transitions to Azure credential input states
-func (m model) handleConfigureAzureCredentialsState() (tea.Model, tea.Cmd) {
-	// Transition to the Azure Client ID input state
-	m.state = stateConfigureAzureClientID
-	return m, textinput.Blink
+// inlineRefLabels lists the inlined fields answered with a reference but not
+// stored as a variable. Their value would end up in the workflow unresolved.
+func (m model) inlineRefLabels() []string {
+	var labels []string
+	for _, field := range m.cloudProvider.Fields(m.oidc) {
+		if field.Inline && !m.variables[field.Key] && isValueRef(m.credentialValues[field.Key]) {
+			labels = append(labels, field.Label)
+		}
+	}
+	return labels
+}
+
+// startDocker asks about the Docker job when a Dockerfile was found
+func (m model) startDocker() (tea.Model, tea.Cmd) {
+	if m.dockerfile != "" && !m.dockerAsked {
+		m.state = stateDockerOption
+		return m, nil
+	}
+	return m.startEnvironment()
 }
 
-// Azure Credentials States
-func (m model) handleConfigureAzureClientIDState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// handleDockerOptionState processes whether to add the Docker job
+func (m model) handleDockerOptionState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_AZURE_CLIENT_ID", m.workflowNameUpper)
-			m.azureSecrets[secretKey] = m.azureClientIDInput.Value()
-			m.azureClientIDInput.Reset()
-			m.state = stateConfigureAzureClientSecret
-			return m, textinput.Blink
+			selectedOption := m.dockerOption.SelectedItem()
+			if selectedOption != nil {
+				m.dockerAsked = true
+				if selectedOption.FilterValue() == "Yes" {
+					m.dockerRegistries = newDockerRegistryList(m.cloudProvider)
+					m.state = stateDockerRegistry
+				} else {
+					return m.startEnvironment()
+				}
+			}
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
@@ -597,16 +897,29 @@ func (m model) handleConfigureAzureClientIDState(msg tea.Msg, cmd tea.Cmd) (tea.
 	return m, cmd
 }
 
-func (m model) handleConfigureAzureClientSecretState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// handleDockerRegistryState processes the registry choice and prompts for its settings
+func (m model) handleDockerRegistryState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_AZURE_CLIENT_SECRET", m.workflowNameUpper)
-			m.azureSecrets[secretKey] = m.azureClientSecretInput.Value()
-			m.azureClientSecretInput.Reset()
-			m.state = stateConfigureAzureTenantID
-			return m, textinput.Blink
+			selectedRegistry := m.dockerRegistries.SelectedItem()
+			if selectedRegistry == nil {
+				return m, cmd
+			}
+			for _, registry := range githubactions.DockerRegistries {
+				if registry.Name == selectedRegistry.FilterValue() {
+					registry := registry
+					m.dockerRegistry = &registry
+					m.credentialFields = append(m.credentialFields, registry.Fields...)
+				}
+			}
+			if m.credentialIndex < len(m.credentialFields) {
+				m.credentialInput = newCredentialInput(m.credentialFields[m.credentialIndex])
+				m.state = stateCloudCredentials
+				return m, textinput.Blink
+			}
+			return m.finishCredentials()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
@@ -614,33 +927,53 @@ func (m model) handleConfigureAzureClientSecretState(msg tea.Msg, cmd tea.Cmd) (
 	return m, cmd
 }
 
-func (m model) handleConfigureAzureTenantIDState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// handleDockerPlatformsState processes the multi-arch platform selection
+func (m model) handleDockerPlatformsState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_AZURE_TENANT_ID", m.workflowNameUpper)
-			m.azureSecrets[secretKey] = m.azureTenantIDInput.Value()
-			m.azureTenantIDInput.Reset()
-			m.state = stateConfigureAzureSubscriptionID
-			return m, textinput.Blink
+			selected := m.dockerPlatformOptions.Selected()
+			if len(selected) == 0 {
+				return m, cmd
+			}
+			m.dockerPlatforms = make([]string, 0, len(selected))
+			for _, i := range selected {
+				m.dockerPlatforms = append(m.dockerPlatforms, m.dockerPlatformOptions.items[i])
+			}
+			return m.startDocker()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
 	}
+	m.dockerPlatformOptions = m.dockerPlatformOptions.Update(msg)
 	return m, cmd
 }
 
-func (m model) handleConfigureAzureSubscriptionIDState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// handleDeployTargetState processes the deployment choice and prompts for its settings
+func (m model) handleDeployTargetState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_AZURE_SUBSCRIPTION_ID", m.workflowNameUpper)
-			m.azureSecrets[secretKey] = m.azureSubscriptionIDInput.Value()
-			m.azureSubscriptionIDInput.Reset()
-			m.state = stateConfigureSecretsOption
-			return m, textinput.Blink
+			m.deployTargetChosen = true
+			selectedTarget := m.deployTargets.SelectedItem()
+			if selectedTarget == nil {
+				return m.startEnvironment()
+			}
+			for _, target := range m.cloudProvider.DeployTargets() {
+				if target.Name == selectedTarget.FilterValue() {
+					target := target
+					m.deployTarget = &target
+					m.credentialFields = append(m.credentialFields, target.Fields...)
+				}
+			}
+			if m.credentialIndex < len(m.credentialFields) {
+				m.credentialInput = newCredentialInput(m.credentialFields[m.credentialIndex])
+				m.state = stateCloudCredentials
+				return m, textinput.Blink
+			}
+			return m.finishCredentials()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
@@ -648,23 +981,49 @@ func (m model) handleConfigureAzureSubscriptionIDState(msg tea.Msg, cmd tea.Cmd)
 	return m, cmd
 }
 
-// handleConfigureGCPCredentialsState transitions to GCP credential input states
-func (m model) handleConfigureGCPCredentialsState() (tea.Model, tea.Cmd) {
-	// Transition to the GCP Service Account Key input state
-	m.state = stateConfigureGCPServiceAccountKey
-	return m, textinput.Blink
+// startEnvironment offers a GitHub Environment for the deploy job, then
+// moves on to configuring secrets
+func (m model) startEnvironment() (tea.Model, tea.Cmd) {
+	if m.cloudProvider != nil && m.deployTarget != nil && m.environment.Name == "" {
+		m.state = stateEnvironment
+		return m, nil
+	}
+	m.state = stateConfigureSecretsOption
+	return m, nil
 }
 
-// GCP Credentials States
-func (m model) handleConfigureGCPServiceAccountKeyState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// handleEnvironmentState processes the environment choice
+func (m model) handleEnvironmentState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_GOOGLE_APPLICATION_CREDENTIALS_JSON", m.workflowNameUpper)
-			m.gcpSecrets[secretKey] = m.gcpServiceAccountKeyInput.Value()
-			m.gcpServiceAccountKeyInput.Reset()
-			m.state = stateConfigureGCPProjectID
+			selectedEnv := m.environmentOption.SelectedItem()
+			if selectedEnv == nil {
+				return m, cmd
+			}
+			switch name := selectedEnv.FilterValue(); name {
+			case noEnvironment:
+				if len(m.accounts) > 0 {
+					m.environmentError = "each additional account needs its own environment"
+					return m, cmd
+				}
+				m.environmentError = ""
+				return m.finishEnvironment()
+			case otherEnvironment:
+				m.environmentError = ""
+				m.environmentInput = newEnvironmentInput("Enter the environment name")
+				m.state = stateEnvironmentName
+			default:
+				if m.environmentTaken(name) {
+					m.environmentError = name + " already has an account, pick another environment"
+					return m, cmd
+				}
+				m.environmentError = ""
+				m.environment.Name = name
+				m.environmentInput = newEnvironmentInput("e.g. octocat, my-org/release-team (leave empty for none)")
+				m.state = stateEnvironmentReviewers
+			}
 			return m, textinput.Blink
 		case "ctrl+c", "q":
 			return m, tea.Quit
@@ -673,16 +1032,66 @@ func (m model) handleConfigureGCPServiceAccountKeyState(msg tea.Msg, cmd tea.Cmd
 	return m, cmd
 }
 
-func (m model) handleConfigureGCPProjectIDState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+// handleEnvironmentInputState processes the environment name, reviewers and
+// wait timer, which share a single text input
+func (m model) handleEnvironmentInputState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			secretKey := fmt.Sprintf("%s_GCP_PROJECT_ID", m.workflowNameUpper)
-			m.gcpSecrets[secretKey] = m.gcpProjectIDInput.Value()
-			m.gcpProjectIDInput.Reset()
-			m.state = stateConfigureSecretsOption
+			value := strings.TrimSpace(m.environmentInput.Value())
+			switch m.state {
+			case stateEnvironmentName:
+				if value == "" {
+					m.environmentError = "the environment name cannot be empty"
+					return m, cmd
+				}
+				if m.environmentTaken(value) {
+					m.environmentError = value + " already has an account, pick another environment"
+					return m, cmd
+				}
+				m.environment.Name = value
+				m.environmentInput = newEnvironmentInput("e.g. octocat, my-org/release-team (leave empty for none)")
+				m.state = stateEnvironmentReviewers
+			case stateEnvironmentReviewers:
+				m.environment.Reviewers = parseReviewers(value)
+				m.environmentInput = newEnvironmentInput("minutes to wait before deploying (leave empty for 0)")
+				m.state = stateEnvironmentWaitTimer
+			case stateEnvironmentWaitTimer:
+				minutes := 0
+				if value != "" {
+					n, err := strconv.Atoi(value)
+					if err != nil || n < 0 || n > maxWaitTimer {
+						m.environmentError = fmt.Sprintf("enter a number of minutes between 0 and %d", maxWaitTimer)
+						return m, cmd
+					}
+					minutes = n
+				}
+				m.environment.WaitTimer = minutes
+				m.environmentError = ""
+				m.state = stateEnvironmentBranchPolicy
+				return m, nil
+			}
+			m.environmentError = ""
 			return m, textinput.Blink
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	return m, cmd
+}
+
+// handleEnvironmentBranchPolicyState processes the deployment branch policy
+func (m model) handleEnvironmentBranchPolicyState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			selectedPolicy := m.branchPolicyOption.SelectedItem()
+			if selectedPolicy != nil {
+				m.environment.ProtectedBranches = selectedPolicy.FilterValue() == "Protected branches only"
+				return m.finishEnvironment()
+			}
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
@@ -701,7 +1110,7 @@ func (m model) handleConfigureSecretsOptionState(msg tea.Msg, cmd tea.Cmd) (tea.
 				choice := selectedOption.FilterValue()
 				if choice == "Yes" {
 					m.configureSecrets = true
-					m.state = stateGitHubUsername
+					return m.startGitHubRepo()
 				} else {
 					m.configureSecrets = false
 					m.state = stateComplete
@@ -714,6 +1123,48 @@ func (m model) handleConfigureSecretsOptionState(msg tea.Msg, cmd tea.Cmd) (tea.
 	return m, cmd
 }
 
+// startGitHubRepo prefills the repository from the git remotes, asking
+// which remote to use when there are several
+func (m model) startGitHubRepo() (tea.Model, tea.Cmd) {
+	switch len(m.gitRemotes) {
+	case 0:
+	case 1:
+		m = m.useGitRemote(m.gitRemotes[0])
+	default:
+		m.state = stateGitRemote
+		return m, nil
+	}
+	m.state = stateGitHubUsername
+	return m, textinput.Blink
+}
+
+// useGitRemote prefills the owner and repository name from a remote
+func (m model) useGitRemote(remote gitRemote) model {
+	m.gitRemote = &remote
+	m.githubServer, m.gitRemoteWarning = serverForRemote(m.githubServer, remote.Host)
+	m.githubUsernameInput.SetValue(remote.Owner)
+	m.githubRepoNameInput.SetValue(remote.Repo)
+	return m
+}
+
+// handleGitRemoteState processes the remote choice
+func (m model) handleGitRemoteState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if i := m.gitRemoteOption.Index(); i >= 0 && i < len(m.gitRemotes) {
+				m = m.useGitRemote(m.gitRemotes[i])
+				m.state = stateGitHubUsername
+				return m, textinput.Blink
+			}
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		}
+	}
+	return m, cmd
+}
+
 // GitHub Credentials States
 func (m model) handleGitHubUsernameState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -738,8 +1189,7 @@ func (m model) handleGitHubRepoNameState(msg tea.Msg, cmd tea.Cmd) (tea.Model, t
 		case "enter":
 			m.githubRepoName = m.githubRepoNameInput.Value()
 			m.githubRepoNameInput.Reset()
-			m.state = stateGitHubToken
-			return m, textinput.Blink
+			return m.startGitHubToken()
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
@@ -747,18 +1197,160 @@ func (m model) handleGitHubRepoNameState(msg tea.Msg, cmd tea.Cmd) (tea.Model, t
 	return m, cmd
 }
 
+// startGitHubToken uses a discovered token when there is one and only
+// prompts for a token otherwise
+func (m model) startGitHubToken() (tea.Model, tea.Cmd) {
+	if token, source := discoverToken(m.githubServer.Host()); token != "" {
+		secretValues.Add(token)
+		m.githubToken = token
+		m.githubTokenSource = source
+		return m.startSecretPreview()
+	}
+	m.state = stateGitHubToken
+	return m, textinput.Blink
+}
+
 func (m model) handleGitHubTokenState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "enter":
-			m.githubToken = m.githubTokenInput.Value()
+			m.githubToken = strings.TrimSpace(m.githubTokenInput.Value())
+			m.githubTokenSource = "prompt"
+			secretValues.Add(m.githubToken)
 			m.githubTokenInput.Reset()
+			return m.startSecretPreview()
+		case "ctrl+r":
+			toggleReveal(&m.githubTokenInput)
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+	return m, cmd
+}
+
+// secretPreview lists the names the wizard will upload for one account
+type secretPreview struct {
+	target    string
+	secrets   []string
+	variables []string
+	existing  map[string]bool // secrets already in the target, upper-cased
+}
+
+// existingSecretsMsg carries the secrets already present where each account
+// is about to be uploaded, in account order
+type existingSecretsMsg struct {
+	names []map[string]bool
+	err   error
+}
+
+// startSecretPreview lists the final secret and variable names and checks
+// which secrets already exist before anything is uploaded
+func (m model) startSecretPreview() (tea.Model, tea.Cmd) {
+	if m.cloudProvider == nil {
+		m.state = stateComplete
+		return m, nil
+	}
+	m.previews = nil
+	for _, account := range m.allAccounts() {
+		cloudConfig := m.cloudConfigFor(account)
+		secrets := m.cloudProvider.Secrets(cloudConfig)
+		target := m.githubUsername + "/" + m.githubRepoName
+		if env := m.secretEnvironment(account); env != "" {
+			target += " (environment " + env + ")"
+		}
+		m.previews = append(m.previews, secretPreview{
+			target:    target,
+			secrets:   sortedKeys(secrets),
+			variables: sortedKeys(m.cloudProvider.Variables(cloudConfig)),
+		})
+		wipeSecrets(secrets)
+	}
+	if !m.hasPreviewNames() {
+		m.state = stateComplete
+		return m, nil
+	}
+	m.state = stateSecretPreview
+	return m, m.existingSecretsCmd()
+}
+
+// existingSecretsCmd lists the secrets in each account's target
+func (m model) existingSecretsCmd() tea.Cmd {
+	return func() tea.Msg {
+		var result existingSecretsMsg
+		ctx := context.Background()
+		client, err := newGitHubClient(ctx, m.githubToken, m.githubServer)
+		if err != nil {
+			return existingSecretsMsg{err: err}
+		}
+		for _, account := range m.allAccounts() {
+			store, err := m.secretStoreFor(ctx, client, account)
+			if err != nil {
+				return existingSecretsMsg{err: err}
+			}
+			names, err := store.Names(ctx)
+			// The environment may only be created by the upload
+			var apiErr *github.ErrorResponse
+			if errors.As(err, &apiErr) && apiErr.Response.StatusCode == http.StatusNotFound && m.secretEnvironment(account) != "" {
+				names, err = map[string]bool{}, nil
+			}
+			if err != nil {
+				return existingSecretsMsg{err: err}
+			}
+			result.names = append(result.names, names)
+		}
+		return result
+	}
+}
+
+// handleSecretPreviewState shows the names to be uploaded. Enter carries on
+// straight into generating the workflow and uploading.
+func (m model) handleSecretPreviewState(msg tea.Msg, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case existingSecretsMsg:
+		m.existingChecked = true
+		m.existingSecretsErr = msg.err
+		for i, names := range msg.names {
+			if i < len(m.previews) {
+				// Secret names are case-insensitive
+				m.previews[i].existing = make(map[string]bool, len(names))
+				for name := range names {
+					m.previews[i].existing[strings.ToUpper(name)] = true
+				}
+			}
+		}
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
 			m.state = stateComplete
-			return m, textinput.Blink
+			return m.handleCompleteState(msg, cmd)
 		case "ctrl+c", "q":
 			return m, tea.Quit
 		}
 	}
 	return m, cmd
 }
+
+// hasPreviewNames reports whether any secret or variable is to be uploaded
+func (m model) hasPreviewNames() bool {
+	for _, preview := range m.previews {
+		if len(preview.secrets) > 0 || len(preview.variables) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// collidingSecrets counts the previewed secrets that already exist
+func (m model) collidingSecrets() int {
+	count := 0
+	for _, preview := range m.previews {
+		for _, name := range preview.secrets {
+			if preview.existing[strings.ToUpper(name)] {
+				count++
+			}
+		}
+	}
+	return count
+}
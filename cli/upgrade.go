@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"workflo/githubactions"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// upgradeChange is a single outdated `uses:` reference and whether the user
+// accepted rewriting it
+type upgradeChange struct {
+	file     string
+	ref      githubactions.UsesRef
+	latest   string
+	accepted bool
+}
+
+func (c upgradeChange) String() string {
+	return fmt.Sprintf("%s:%d %s -> %s", c.file, c.ref.Line+1, c.ref, c.latest)
+}
+
+// runUpgrade finds actions behind their latest known major version and
+// rewrites them in place, asking for each change unless --yes is given
+func runUpgrade(args []string) error {
+	fs := flag.NewFlagSet("upgrade", flag.ContinueOnError)
+	dir := fs.String("dir", githubactions.WorkflowsDir, "directory containing the workflow files")
+	yes := fs.Bool("yes", false, "apply every upgrade without asking")
+	dryRun := fs.Bool("dry-run", false, "list outdated actions without changing any files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	changes, err := findUpgrades(*dir)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(stdout, "All actions are up to date.")
+		return nil
+	}
+
+	if *dryRun {
+		for _, change := range changes {
+			fmt.Fprintln(stdout, change)
+		}
+		return nil
+	}
+
+	if !*yes {
+		final, err := tea.NewProgram(newUpgradeModel(changes)).Run()
+		if err != nil {
+			return fmt.Errorf("error running upgrade selection: %v", err)
+		}
+		result := final.(upgradeModel)
+		if !result.confirmed {
+			fmt.Fprintln(stdout, "Upgrade cancelled, no files were changed.")
+			return nil
+		}
+		changes = result.changes
+	}
+
+	return applyUpgrades(changes)
+}
+
+// findUpgrades scans every workflow file for outdated action references
+func findUpgrades(dir string) ([]upgradeChange, error) {
+	files, err := githubactions.WorkflowFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading workflow directory: %v", err)
+	}
+
+	var changes []upgradeChange
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", file, err)
+		}
+		for _, ref := range githubactions.FindUses(string(data)) {
+			if latest, ok := githubactions.LatestMajor(ref); ok {
+				changes = append(changes, upgradeChange{
+					file:     file,
+					ref:      ref,
+					latest:   latest,
+					accepted: true,
+				})
+			}
+		}
+	}
+	return changes, nil
+}
+
+// applyUpgrades rewrites the accepted changes, touching only the `uses:` lines
+func applyUpgrades(changes []upgradeChange) error {
+	byFile := make(map[string][]upgradeChange)
+	var files []string
+	for _, change := range changes {
+		if !change.accepted {
+			continue
+		}
+		if _, ok := byFile[change.file]; !ok {
+			files = append(files, change.file)
+		}
+		byFile[change.file] = append(byFile[change.file], change)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", file, err)
+		}
+		content := string(data)
+		for _, change := range byFile[file] {
+			content = githubactions.ReplaceUses(content, change.ref, change.latest, "")
+			// A version comment names the old release, other comments are kept
+			if githubactions.IsVersionComment(change.ref.Comment) {
+				content = githubactions.StripUsesComment(content, change.ref.Line)
+			}
+			fmt.Fprintf(stdout, "Upgraded %s\n", change)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", file, err)
+		}
+	}
+
+	if len(files) == 0 {
+		fmt.Fprintln(stdout, "No upgrades selected.")
+	}
+	return nil
+}
+
+// upgradeModel is the accept/reject checklist shown by `workflo upgrade`
+type upgradeModel struct {
+	changes   []upgradeChange
+	list      checklist
+	confirmed bool
+}
+
+func newUpgradeModel(changes []upgradeChange) upgradeModel {
+	labels := make([]string, len(changes))
+	for i, change := range changes {
+		labels[i] = change.String()
+	}
+	return upgradeModel{
+		changes: changes,
+		list:    newChecklist("Outdated actions found. Select the upgrades to apply:", labels, true),
+	}
+}
+
+// Init implements tea.Model
+func (m upgradeModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update toggles changes and confirms the selection
+func (m upgradeModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			changes := append([]upgradeChange(nil), m.changes...)
+			for i := range changes {
+				changes[i].accepted = m.list.checked[i]
+			}
+			m.changes = changes
+			m.confirmed = true
+			return m, tea.Quit
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		}
+	}
+	m.list = m.list.Update(msg)
+	return m, nil
+}
+
+// View renders the checklist of outdated actions
+func (m upgradeModel) View() string {
+	return m.list.View() + "(q to cancel)\n"
+}
@@ -0,0 +1,442 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+)
+
+const (
+	// uploadWorkers bounds the number of secrets uploaded at once
+	uploadWorkers = 4
+	// maxUploadAttempts is how often a request is tried before giving up
+	maxUploadAttempts = 4
+)
+
+// retryBaseDelay is the first backoff delay, doubled on every retry
+var retryBaseDelay = time.Second
+
+// secretStore is a place secrets are uploaded to, such as a repository or
+// one of its environments
+type secretStore interface {
+	PublicKey(ctx context.Context) (*github.PublicKey, *github.Response, error)
+	Put(ctx context.Context, secret *github.EncryptedSecret) (*github.Response, error)
+	Delete(ctx context.Context, name string) (*github.Response, error)
+	// List fetches one page of the secrets in the store
+	List(ctx context.Context, opts *github.ListOptions) (*github.Secrets, *github.Response, error)
+	// Names lists the secrets that currently exist in the store
+	Names(ctx context.Context) (map[string]bool, error)
+	String() string
+}
+
+// listSecrets fetches every page of a store's secrets
+func listSecrets(ctx context.Context, store secretStore) ([]*github.Secret, error) {
+	var all []*github.Secret
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		secrets, resp, err := store.List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, secrets.Secrets...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// listSecretNames collects the names from every page of a secrets listing
+func listSecretNames(list func(opts *github.ListOptions) (*github.Secrets, *github.Response, error)) (map[string]bool, error) {
+	names := make(map[string]bool)
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		secrets, resp, err := list(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range secrets.Secrets {
+			names[secret.Name] = true
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// storeSecretNames collects the names of every secret in a store
+func storeSecretNames(ctx context.Context, store secretStore) (map[string]bool, error) {
+	return listSecretNames(func(opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+		return store.List(ctx, opts)
+	})
+}
+
+// repoSecretStore holds repository Actions secrets
+type repoSecretStore struct {
+	client      *github.Client
+	owner, repo string
+}
+
+func (s repoSecretStore) PublicKey(ctx context.Context) (*github.PublicKey, *github.Response, error) {
+	return s.client.Actions.GetRepoPublicKey(ctx, s.owner, s.repo)
+}
+
+func (s repoSecretStore) Put(ctx context.Context, secret *github.EncryptedSecret) (*github.Response, error) {
+	return s.client.Actions.CreateOrUpdateRepoSecret(ctx, s.owner, s.repo, secret)
+}
+
+func (s repoSecretStore) Delete(ctx context.Context, name string) (*github.Response, error) {
+	return s.client.Actions.DeleteRepoSecret(ctx, s.owner, s.repo, name)
+}
+
+func (s repoSecretStore) List(ctx context.Context, opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+	return s.client.Actions.ListRepoSecrets(ctx, s.owner, s.repo, opts)
+}
+
+func (s repoSecretStore) Names(ctx context.Context) (map[string]bool, error) {
+	return storeSecretNames(ctx, s)
+}
+
+func (s repoSecretStore) String() string { return s.owner + "/" + s.repo }
+
+// envSecretStore holds the secrets of a repository environment
+type envSecretStore struct {
+	client *github.Client
+	repoID int
+	repo   string // owner/name, for messages
+	env    string
+}
+
+func (s envSecretStore) PublicKey(ctx context.Context) (*github.PublicKey, *github.Response, error) {
+	return s.client.Actions.GetEnvPublicKey(ctx, s.repoID, s.env)
+}
+
+func (s envSecretStore) Put(ctx context.Context, secret *github.EncryptedSecret) (*github.Response, error) {
+	return s.client.Actions.CreateOrUpdateEnvSecret(ctx, s.repoID, s.env, secret)
+}
+
+func (s envSecretStore) Delete(ctx context.Context, name string) (*github.Response, error) {
+	return s.client.Actions.DeleteEnvSecret(ctx, s.repoID, s.env, name)
+}
+
+func (s envSecretStore) List(ctx context.Context, opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+	return s.client.Actions.ListEnvSecrets(ctx, s.repoID, s.env, opts)
+}
+
+func (s envSecretStore) Names(ctx context.Context) (map[string]bool, error) {
+	return storeSecretNames(ctx, s)
+}
+
+func (s envSecretStore) String() string { return s.repo + " (environment " + s.env + ")" }
+
+// orgSecretStore holds organization Actions secrets, readable by the
+// repositories their visibility allows
+type orgSecretStore struct {
+	client     *github.Client
+	org        string
+	visibility string                 // all, private or selected
+	repoIDs    github.SelectedRepoIDs // the repositories, with visibility selected
+}
+
+func (s orgSecretStore) PublicKey(ctx context.Context) (*github.PublicKey, *github.Response, error) {
+	return s.client.Actions.GetOrgPublicKey(ctx, s.org)
+}
+
+func (s orgSecretStore) Put(ctx context.Context, secret *github.EncryptedSecret) (*github.Response, error) {
+	secret.Visibility = s.visibility
+	if s.visibility == orgVisibilitySelected {
+		secret.SelectedRepositoryIDs = s.repoIDs
+	}
+	return s.client.Actions.CreateOrUpdateOrgSecret(ctx, s.org, secret)
+}
+
+func (s orgSecretStore) Delete(ctx context.Context, name string) (*github.Response, error) {
+	return s.client.Actions.DeleteOrgSecret(ctx, s.org, name)
+}
+
+func (s orgSecretStore) List(ctx context.Context, opts *github.ListOptions) (*github.Secrets, *github.Response, error) {
+	return s.client.Actions.ListOrgSecrets(ctx, s.org, opts)
+}
+
+func (s orgSecretStore) Names(ctx context.Context) (map[string]bool, error) {
+	return storeSecretNames(ctx, s)
+}
+
+func (s orgSecretStore) String() string { return "organization " + s.org }
+
+// secretResult is the outcome of uploading or deleting a single secret
+type secretResult struct {
+	Name     string
+	Attempts int
+	Err      error
+	// Existed records whether the secret existed before the upload. Values
+	// cannot be read back, so an overwritten secret cannot be restored.
+	Existed bool
+	Action  string // what was done on success: created, updated or deleted
+}
+
+// secretReport lists the outcome of every secret in an upload, by name
+type secretReport []secretResult
+
+// Failed returns the secrets that could not be uploaded
+func (r secretReport) Failed() []secretResult {
+	var failed []secretResult
+	for _, result := range r {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Created returns the secrets the upload created
+func (r secretReport) Created() []string {
+	var names []string
+	for _, result := range r {
+		if result.Err == nil && result.Action == "created" {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+// Overwritten returns the existing secrets the upload replaced
+func (r secretReport) Overwritten() []string {
+	var names []string
+	for _, result := range r {
+		if result.Err == nil && result.Action == "updated" {
+			names = append(names, result.Name)
+		}
+	}
+	return names
+}
+
+// Err summarizes the failures, or returns nil when every secret was uploaded
+func (r secretReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	names := make([]string, len(failed))
+	for i, result := range failed {
+		names[i] = result.Name
+	}
+	return fmt.Errorf("%d of %d secret(s) failed: %s", len(failed), len(r), strings.Join(names, ", "))
+}
+
+// Table renders the report with one row per secret
+func (r secretReport) Table() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SECRET\tSTATUS\tATTEMPTS")
+	for _, result := range r {
+		status := result.Action
+		if result.Err != nil {
+			status = "failed: " + shortError(result.Err)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\n", result.Name, status, result.Attempts)
+	}
+	w.Flush()
+	return b.String()
+}
+
+// shortError describes an API error by its status and message, without the
+// request line go-github puts in front
+func shortError(err error) string {
+	var apiErr *github.ErrorResponse
+	if errors.As(err, &apiErr) && apiErr.Response != nil {
+		if apiErr.Message == "" {
+			return apiErr.Response.Status
+		}
+		return fmt.Sprintf("%d %s", apiErr.Response.StatusCode, apiErr.Message)
+	}
+	return err.Error()
+}
+
+// printReport prints the per-secret table of an upload and returns an error
+// when the upload failed or any secret in it did
+func printReport(report secretReport, err error) error {
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(stdout, report.Table())
+	if len(report.Failed()) > 0 {
+		if created := report.Created(); len(created) > 0 {
+			fmt.Fprintf(stdout, "Created by this run: %s\n", strings.Join(created, ", "))
+		}
+		if overwritten := report.Overwritten(); len(overwritten) > 0 {
+			fmt.Fprintf(stdout, "Overwritten, previous values cannot be restored: %s\n", strings.Join(overwritten, ", "))
+		}
+	}
+	return report.Err()
+}
+
+// uploadSecrets encrypts and uploads secrets to store with a bounded pool of
+// workers. The public key is fetched once. A failing secret does not stop the
+// others; the error is only set when nothing could be uploaded at all.
+func uploadSecrets(ctx context.Context, store secretStore, secrets map[string]string) (secretReport, error) {
+	// Keep the values out of any output, including error messages
+	for _, value := range secrets {
+		secretValues.Add(value)
+	}
+
+	var publicKey *github.PublicKey
+	_, err := withRetry(ctx, func() (*github.Response, error) {
+		var resp *github.Response
+		var err error
+		publicKey, resp, err = store.PublicKey(ctx)
+		return resp, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error getting public key for %s: %v", store, err)
+	}
+
+	// Remember what existed so a failed run can be rolled back
+	existing, err := store.Names(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing secrets in %s: %v", store, err)
+	}
+
+	names := sortedKeys(secrets)
+
+	report := make(secretReport, len(names))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < uploadWorkers && w < len(names); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				report[i] = uploadSecret(ctx, store, publicKey, names[i], secrets[names[i]])
+				// GitHub lists names upper-cased but accepts any case
+				report[i].Existed = existing[strings.ToUpper(names[i])]
+				if report[i].Err == nil {
+					report[i].Action = "created"
+					if report[i].Existed {
+						report[i].Action = "updated"
+					}
+				}
+			}
+		}()
+	}
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return report, nil
+}
+
+// deleteSecrets removes the named secrets from store, e.g. to roll back the
+// secrets a failed run created
+func deleteSecrets(ctx context.Context, store secretStore, names []string) secretReport {
+	report := make(secretReport, len(names))
+	for i, name := range names {
+		attempts, err := withRetry(ctx, func() (*github.Response, error) {
+			return store.Delete(ctx, name)
+		})
+		report[i] = secretResult{Name: name, Attempts: attempts, Err: err, Existed: true}
+		if err == nil {
+			report[i].Action = "deleted"
+		}
+	}
+	return report
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// uploadSecret encrypts and uploads one secret, retrying transient failures
+func uploadSecret(ctx context.Context, store secretStore, publicKey *github.PublicKey, name, value string) secretResult {
+	encryptedValue, err := encryptSecret([]byte(value), publicKey.GetKey())
+	if err != nil {
+		return secretResult{Name: name, Err: fmt.Errorf("error encrypting secret: %v", err)}
+	}
+	secret := &github.EncryptedSecret{
+		Name:           name,
+		KeyID:          publicKey.GetKeyID(),
+		EncryptedValue: encryptedValue,
+	}
+
+	attempts, err := withRetry(ctx, func() (*github.Response, error) {
+		return store.Put(ctx, secret)
+	})
+	return secretResult{Name: name, Attempts: attempts, Err: err}
+}
+
+// withRetry runs op until it succeeds, fails permanently or runs out of
+// attempts, backing off exponentially between tries. It returns the number of
+// attempts made.
+func withRetry(ctx context.Context, op func() (*github.Response, error)) (int, error) {
+	var err error
+	for attempt := 1; ; attempt++ {
+		var resp *github.Response
+		resp, err = op()
+		if err == nil {
+			return attempt, nil
+		}
+		delay, retry := retryDelay(resp, err, attempt)
+		if !retry || attempt == maxUploadAttempts {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryDelay decides whether a failed request is worth retrying: secondary
+// rate limits and server errors are, anything else is not. The delay honours
+// Retry-After and otherwise doubles per attempt with some jitter.
+func retryDelay(resp *github.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := retryBaseDelay << (attempt - 1)
+	backoff += time.Duration(rand.Int63n(int64(backoff)/2 + 1))
+
+	var abuse *github.AbuseRateLimitError
+	if errors.As(err, &abuse) {
+		if abuse.RetryAfter != nil {
+			return *abuse.RetryAfter, true
+		}
+		return backoff, true
+	}
+
+	if resp == nil || resp.Response == nil {
+		return 0, false
+	}
+	switch status := resp.StatusCode; {
+	case status >= 500:
+		return backoff, true
+	case status == http.StatusTooManyRequests || status == http.StatusForbidden:
+		// go-github only recognises the older abuse rate limit documentation
+		// link, so detect secondary rate limits by their message or header
+		retryAfter := resp.Header.Get("Retry-After")
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if status == http.StatusTooManyRequests || strings.Contains(strings.ToLower(err.Error()), "secondary rate limit") {
+			return backoff, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+	"workflo/githubactions"
+)
+
+// Prefixes of value references, which name where a secret is read from
+// instead of holding it, e.g. exec:pass show aws/key
+const (
+	execRefPrefix = "exec:"
+	fileRefPrefix = "file:"
+	envRefPrefix  = "env:"
+)
+
+// execRefTimeout bounds how long a command reference may run, e.g. while a
+// password manager waits to be unlocked
+var execRefTimeout = 2 * time.Minute
+
+// isValueRef reports whether value is a reference rather than a value
+func isValueRef(value string) bool {
+	for _, prefix := range []string{execRefPrefix, fileRefPrefix, envRefPrefix} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValueRef rejects a reference that does not say what to read,
+// without resolving it
+func checkValueRef(ref string) error {
+	_, target, _ := strings.Cut(ref, ":")
+	if strings.TrimSpace(target) == "" {
+		return fmt.Errorf("%q does not say what to read, e.g. exec:pass show aws/key, file:key.json or env:AWS_KEY", ref)
+	}
+	return nil
+}
+
+// resolveValueRef returns the value a reference points to. Values that are
+// not references are returned unchanged. Resolved values are redacted from
+// output.
+func resolveValueRef(ctx context.Context, value string) (string, error) {
+	if !isValueRef(value) {
+		return value, nil
+	}
+	if err := checkValueRef(value); err != nil {
+		return "", err
+	}
+
+	var resolved string
+	switch {
+	case strings.HasPrefix(value, execRefPrefix):
+		command := strings.TrimSpace(strings.TrimPrefix(value, execRefPrefix))
+		out, err := runValueCommand(ctx, command)
+		if err != nil {
+			return "", err
+		}
+		resolved = out
+	case strings.HasPrefix(value, fileRefPrefix):
+		path := strings.TrimSpace(strings.TrimPrefix(value, fileRefPrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("error reading %s: %v", path, err)
+		}
+		resolved = string(data)
+	default:
+		name := strings.TrimSpace(strings.TrimPrefix(value, envRefPrefix))
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		resolved = v
+	}
+
+	// Commands and files usually end with a newline that is not part of the value
+	resolved = strings.TrimSuffix(strings.TrimSuffix(resolved, "\n"), "\r")
+	if resolved == "" {
+		return "", fmt.Errorf("%s resolved to an empty value", value)
+	}
+	secretValues.Add(resolved)
+	return resolved, nil
+}
+
+// runValueCommand runs a command reference through the shell and returns
+// its output. Stderr is kept for the error message only. The command gets
+// no terminal, since the wizard may be using it, so it must not prompt.
+func runValueCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, execRefTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("error running %q: %v: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("error running %q: %v", command, err)
+	}
+	return stdout.String(), nil
+}
+
+// resolveValueRefs resolves every reference in values, returning a copy
+func resolveValueRefs(ctx context.Context, values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(values))
+	for _, name := range sortedKeys(values) {
+		v, err := resolveValueRef(ctx, values[name])
+		if err != nil {
+			wipeSecrets(resolved)
+			return nil, fmt.Errorf("error resolving %s: %v", name, err)
+		}
+		resolved[name] = v
+	}
+	return resolved, nil
+}
+
+// resolveCredentialValues resolves the references among the wizard's
+// answers and validates the values they resolve to
+func resolveCredentialValues(ctx context.Context, fields []githubactions.CredentialField, values map[string]string) (map[string]string, error) {
+	resolved, err := resolveValueRefs(ctx, values)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		if field.Validate == nil || !isValueRef(values[field.Key]) {
+			continue
+		}
+		if err := field.Validate(resolved[field.Key]); err != nil {
+			wipeSecrets(resolved)
+			return nil, fmt.Errorf("invalid %s from %s: %v", field.Label, values[field.Key], err)
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestResolveValueRef(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "secret.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"value-for-$1\"\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "key.json")
+	if err := os.WriteFile(keyFile, []byte("{\"key\":1}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WORKFLO_TEST_REF", "from-env")
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"exec:" + script + " aws", "value-for-aws"},
+		{"file:" + keyFile, `{"key":1}`},
+		{"env:WORKFLO_TEST_REF", "from-env"},
+		{"plain value", "plain value"},
+	}
+	for _, tt := range tests {
+		got, err := resolveValueRef(context.Background(), tt.ref)
+		if err != nil {
+			t.Errorf("resolveValueRef(%q): %v", tt.ref, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveValueRef(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestResolveValueRefErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	dir := t.TempDir()
+	failing := filepath.Join(dir, "fail.sh")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho 'vault locked' >&2\nexit 3\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"exec:" + failing, "vault locked"},
+		{"exec:true", "empty value"},
+		{"file:" + filepath.Join(dir, "missing"), "error reading"},
+		{"env:WORKFLO_TEST_UNSET", "is not set"},
+		{"file:", "does not say what to read"},
+	}
+	for _, tt := range tests {
+		_, err := resolveValueRef(context.Background(), tt.ref)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("resolveValueRef(%q) error = %v, want it to contain %q", tt.ref, err, tt.want)
+		}
+	}
+}
+
+func TestRunValueCommandHasNoStdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	// A command that tries to read a prompt answer gets end of file at once
+	// instead of waiting for the terminal
+	out, err := runValueCommand(context.Background(), "read answer && echo \"$answer\"")
+	if err == nil {
+		t.Fatalf("runValueCommand succeeded with %q, want the read to fail", out)
+	}
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"workflo/githubactions"
+
+	"github.com/google/go-github/v41/github"
+)
+
+// actionsVariable is the request body of the Actions variables API, which the
+// go-github version in use does not cover
+type actionsVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// configureGitHubVariables creates or updates Actions variables in the
+// repository, or in the environment when env is not empty
+func configureGitHubVariables(ctx context.Context, client *github.Client, owner, repo, env string, variables map[string]string) error {
+	base := fmt.Sprintf("repos/%s/%s/actions/variables", owner, repo)
+	if env != "" {
+		base = fmt.Sprintf("repos/%s/%s/environments/%s/variables", owner, repo, url.PathEscape(env))
+	}
+
+	for name, value := range variables {
+		variable := &actionsVariable{Name: name, Value: value}
+
+		req, err := client.NewRequest(http.MethodPost, base, variable)
+		if err != nil {
+			return fmt.Errorf("error creating variable %s: %v", name, err)
+		}
+		resp, err := client.Do(ctx, req, nil)
+		if err == nil {
+			continue
+		}
+		// Creating an existing variable conflicts, so update it instead
+		if resp == nil || resp.StatusCode != http.StatusConflict {
+			return fmt.Errorf("error creating variable %s: %v", name, err)
+		}
+
+		req, err = client.NewRequest(http.MethodPatch, base+"/"+url.PathEscape(name), variable)
+		if err != nil {
+			return fmt.Errorf("error updating variable %s: %v", name, err)
+		}
+		if _, err := client.Do(ctx, req, nil); err != nil {
+			return fmt.Errorf("error updating variable %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// variableCandidates lists the provider fields the user may store as
+// variables: those the provider can read from the vars context. Sensitive
+// fields and settings are never offered.
+func variableCandidates(provider githubactions.CloudProvider, oidc bool) []githubactions.CredentialField {
+	fields := provider.Fields(oidc)
+	all := githubactions.CloudConfig{OIDC: oidc, Variables: make(map[string]bool)}
+	for _, field := range fields {
+		all.Variables[field.Key] = true
+	}
+	supported := provider.Variables(all)
+
+	var candidates []githubactions.CredentialField
+	for _, field := range fields {
+		if _, ok := supported[field.Key]; ok {
+			candidates = append(candidates, field)
+		}
+	}
+	return candidates
+}
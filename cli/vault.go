@@ -0,0 +1,356 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// vaultEnv overrides the path of the vault file
+	vaultEnv = "WORKFLO_VAULT"
+	// vaultPassphraseEnv supplies the passphrase non-interactively, e.g. in CI
+	vaultPassphraseEnv = "WORKFLO_VAULT_PASSPHRASE"
+)
+
+// scrypt parameters for deriving the vault key, as recommended for
+// interactive logins
+const (
+	vaultScryptN = 1 << 15
+	vaultScryptR = 8
+	vaultScryptP = 1
+)
+
+const vaultUsage = `Usage: workflo secrets vault <command> [flags]
+
+Commands:
+  set NAME...     Store values in the vault, reading them from --value, --stdin or a prompt
+  list            List the names stored in the vault
+  delete NAME...  Remove values from the vault
+
+The vault is encrypted with a passphrase, read from $WORKFLO_VAULT_PASSPHRASE or a prompt.
+` + "`workflo secrets sync`" + ` takes values from the environment, then the vault, then a prompt.
+`
+
+// vaultFile is the on-disk vault: the secret values, encrypted with a key
+// derived from the passphrase
+type vaultFile struct {
+	Version int    `yaml:"version"`
+	Salt    string `yaml:"salt"`
+	Nonce   string `yaml:"nonce"`
+	Data    string `yaml:"data"`
+}
+
+// vaultPath returns the location of the vault, next to the config file by
+// default
+func vaultPath() (string, error) {
+	if path := os.Getenv(vaultEnv); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "workflo", "vault.yml"), nil
+}
+
+// vaultExists reports whether a vault has been created
+func vaultExists() bool {
+	path, err := vaultPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// vaultKey derives the secretbox key from the passphrase
+func vaultKey(passphrase string, salt []byte) (*[32]byte, error) {
+	derived, err := scrypt.Key([]byte(passphrase), salt, vaultScryptN, vaultScryptR, vaultScryptP, 32)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving vault key: %v", err)
+	}
+	var key [32]byte
+	copy(key[:], derived)
+	wipeBytes(derived)
+	return &key, nil
+}
+
+// openVault decrypts the vault. A missing vault is empty.
+func openVault(passphrase string) (map[string]string, error) {
+	values := make(map[string]string)
+	path, err := vaultPath()
+	if err != nil {
+		return nil, fmt.Errorf("error locating vault: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	var file vaultFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if file.Version != 1 {
+		return nil, fmt.Errorf("unsupported vault version %d in %s", file.Version, path)
+	}
+	salt, err1 := base64.StdEncoding.DecodeString(file.Salt)
+	nonceBytes, err2 := base64.StdEncoding.DecodeString(file.Nonce)
+	sealed, err3 := base64.StdEncoding.DecodeString(file.Data)
+	if err1 != nil || err2 != nil || err3 != nil || len(nonceBytes) != 24 {
+		return nil, fmt.Errorf("error parsing %s: corrupt vault", path)
+	}
+	var nonce [24]byte
+	copy(nonce[:], nonceBytes)
+
+	key, err := vaultKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer wipeBytes(key[:])
+	plain, ok := secretbox.Open(nil, sealed, &nonce, key)
+	if !ok {
+		return nil, fmt.Errorf("error opening vault: wrong passphrase or corrupt file")
+	}
+	defer wipeBytes(plain)
+	if err := yaml.Unmarshal(plain, &values); err != nil {
+		return nil, fmt.Errorf("error opening vault: %v", err)
+	}
+	for _, value := range values {
+		secretValues.Add(value)
+	}
+	return values, nil
+}
+
+// saveVault encrypts values with a fresh salt and nonce and writes the vault
+func saveVault(values map[string]string, passphrase string) error {
+	path, err := vaultPath()
+	if err != nil {
+		return fmt.Errorf("error locating vault: %v", err)
+	}
+
+	salt := make([]byte, 16)
+	var nonce [24]byte
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("error generating salt: %v", err)
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return fmt.Errorf("error generating nonce: %v", err)
+	}
+	key, err := vaultKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+	defer wipeBytes(key[:])
+
+	plain, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("error encoding vault: %v", err)
+	}
+	defer wipeBytes(plain)
+	data, err := yaml.Marshal(vaultFile{
+		Version: 1,
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Nonce:   base64.StdEncoding.EncodeToString(nonce[:]),
+		Data:    base64.StdEncoding.EncodeToString(secretbox.Seal(nil, plain, &nonce, key)),
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding vault: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	// Write a temporary file first so an interrupted save keeps the old vault
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// vaultPassphrase reads the passphrase from the environment or a prompt. A
+// new vault's passphrase is asked for twice.
+func vaultPassphrase(create bool) (string, error) {
+	if passphrase := os.Getenv(vaultPassphraseEnv); passphrase != "" {
+		secretValues.Add(passphrase)
+		return passphrase, nil
+	}
+	if !create {
+		return promptSecret("the vault passphrase")
+	}
+	passphrase, err := promptSecret("a passphrase for the new vault")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("the vault passphrase cannot be empty")
+	}
+	confirm, err := promptSecret("the passphrase again")
+	if err != nil {
+		return "", err
+	}
+	if confirm != passphrase {
+		return "", fmt.Errorf("the passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// unlockVault asks for the passphrase and opens the vault, creating an
+// empty one when there is none yet
+func unlockVault() (map[string]string, string, error) {
+	passphrase, err := vaultPassphrase(!vaultExists())
+	if err != nil {
+		return nil, "", err
+	}
+	values, err := openVault(passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+	return values, passphrase, nil
+}
+
+// wipeBytes zeroes sensitive data once it is no longer needed
+func wipeBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// runSecretsVault manages the local vault
+func runSecretsVault(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, vaultUsage)
+		return fmt.Errorf("missing vault command")
+	}
+
+	switch args[0] {
+	case "set":
+		return runVaultSet(args[1:])
+	case "list":
+		return runVaultList(args[1:])
+	case "delete":
+		return runVaultDelete(args[1:])
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, vaultUsage)
+		return nil
+	default:
+		fmt.Fprint(stderr, vaultUsage)
+		return fmt.Errorf("unknown vault command %q", args[0])
+	}
+}
+
+// runVaultSet stores values in the vault under the given names
+func runVaultSet(args []string) error {
+	fs := flag.NewFlagSet("secrets vault set", flag.ContinueOnError)
+	value := fs.String("value", "", "value to store (visible in shell history, prefer --stdin or the prompt)")
+	stdin := fs.Bool("stdin", false, "read the value from standard input")
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("at least one name is required")
+	}
+	if err := validateSecretNames(names); err != nil {
+		return err
+	}
+	if (*value != "" || *stdin) && len(names) > 1 {
+		return fmt.Errorf("--value and --stdin set a single value")
+	}
+
+	values, passphrase, err := unlockVault()
+	if err != nil {
+		return err
+	}
+	defer wipeSecrets(values)
+	entered := make(map[string]string)
+	defer wipeSecrets(entered)
+	if err := readSecretValues(entered, names, *value, *stdin); err != nil {
+		return err
+	}
+	for name, v := range entered {
+		values[name] = v
+	}
+	if err := saveVault(values, passphrase); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Stored %s in the vault.\n", strings.Join(names, ", "))
+	return nil
+}
+
+// runVaultList prints the names in the vault, never the values
+func runVaultList(args []string) error {
+	fs := flag.NewFlagSet("secrets vault list", flag.ContinueOnError)
+	if _, err := parseInterspersed(fs, args); err != nil {
+		return err
+	}
+	if !vaultExists() {
+		fmt.Fprintln(stdout, "The vault is empty.")
+		return nil
+	}
+	values, _, err := unlockVault()
+	if err != nil {
+		return err
+	}
+	defer wipeSecrets(values)
+	if len(values) == 0 {
+		fmt.Fprintln(stdout, "The vault is empty.")
+		return nil
+	}
+	for _, name := range sortedKeys(values) {
+		fmt.Fprintln(stdout, name)
+	}
+	return nil
+}
+
+// runVaultDelete removes names from the vault
+func runVaultDelete(args []string) error {
+	fs := flag.NewFlagSet("secrets vault delete", flag.ContinueOnError)
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("at least one name is required")
+	}
+	if !vaultExists() {
+		return fmt.Errorf("no vault found, store values with `workflo secrets vault set`")
+	}
+	values, passphrase, err := unlockVault()
+	if err != nil {
+		return err
+	}
+	defer wipeSecrets(values)
+	var missing []string
+	for _, name := range names {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+		delete(values, name)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s not found in the vault", strings.Join(missing, ", "))
+	}
+	if err := saveVault(values, passphrase); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Deleted %s from the vault.\n", strings.Join(names, ", "))
+	return nil
+}
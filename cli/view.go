@@ -1,6 +1,9 @@
 package cli
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // View renders the UI based on the current state
 func (m model) View() string {
@@ -32,59 +35,190 @@ func (m model) View() string {
 	case stateCloudProvider:
 		return m.supportedCloud.View()
 
+	case stateCloudAuthMode:
+		return m.cloudAuthMode.View()
+
 	case stateConfigureSecretsOption:
 		return m.configureSecretsOption.View()
 
-	// Add cases for the intermediate cloud configuration states
-	case stateConfigureAWSCredentials:
-		return "Preparing to configure AWS credentials..."
-
-	case stateConfigureAzureCredentials:
-		return "Preparing to configure Azure credentials..."
-
-	case stateConfigureGCPCredentials:
-		return "Preparing to configure GCP credentials..."
-
-	case stateConfigureAWSAccessKeyID:
-		return fmt.Sprintf("Enter AWS Access Key ID:\n\n%s\n\n(Press Enter to continue)", m.awsAccessKeyIDInput.View())
-
-	case stateConfigureAWSSecretAccessKey:
-		return fmt.Sprintf("Enter AWS Secret Access Key:\n\n%s\n\n(Press Enter to continue)", m.awsSecretAccessKeyInput.View())
-
-	case stateConfigureAWSRegion:
-		return fmt.Sprintf("Enter AWS Region:\n\n%s\n\n(Press Enter to continue)", m.awsRegionInput.View())
-
-	case stateConfigureAzureClientID:
-		return fmt.Sprintf("Enter Azure Client ID:\n\n%s\n\n(Press Enter to continue)", m.azureClientIDInput.View())
-
-	case stateConfigureAzureClientSecret:
-		return fmt.Sprintf("Enter Azure Client Secret:\n\n%s\n\n(Press Enter to continue)", m.azureClientSecretInput.View())
-
-	case stateConfigureAzureTenantID:
-		return fmt.Sprintf("Enter Azure Tenant ID:\n\n%s\n\n(Press Enter to continue)", m.azureTenantIDInput.View())
-
-	case stateConfigureAzureSubscriptionID:
-		return fmt.Sprintf("Enter Azure Subscription ID:\n\n%s\n\n(Press Enter to continue)", m.azureSubscriptionIDInput.View())
-
-	case stateConfigureGCPServiceAccountKey:
-		return fmt.Sprintf("Enter GCP Service Account Key (JSON):\n\n%s\n\n(Press Enter to continue)", m.gcpServiceAccountKeyInput.View())
-
-	case stateConfigureGCPProjectID:
-		return fmt.Sprintf("Enter GCP Project ID:\n\n%s\n\n(Press Enter to continue)", m.gcpProjectIDInput.View())
+	case stateCloudCredentials:
+		field := m.credentialFields[m.credentialIndex]
+		errorLine := ""
+		if m.credentialError != "" {
+			errorLine = fmt.Sprintf("Invalid value: %s\n\n", m.credentialError)
+		}
+		help := "(Press Enter to continue)"
+		if field.Sensitive {
+			help = "(Press Enter to continue, Ctrl+R to show or hide the value)"
+		}
+		if m.acceptsValueRef(field) {
+			help = "Enter a value, or read it at upload time with exec:COMMAND, file:PATH or env:VAR.\n" +
+				"Commands cannot prompt while the wizard runs, so unlock password managers first.\n" + help
+		}
+		account := ""
+		if len(m.accounts) > 0 {
+			account = fmt.Sprintf("Account %d: ", len(m.accounts)+1)
+		}
+		return fmt.Sprintf("%sEnter %s (%d/%d):\n\n%s\n\n%s%s",
+			account, field.Label, m.credentialIndex+1, len(m.credentialFields), m.credentialInput.View(), errorLine, help)
+
+	case stateVariableFields:
+		if m.variableError != "" {
+			return fmt.Sprintf("%s\n\nInvalid choice: %s", m.variableOptions.View(), m.variableError)
+		}
+		return m.variableOptions.View()
+
+	case stateDeployTarget:
+		return m.deployTargets.View()
+
+	case stateDockerOption:
+		return m.dockerOption.View()
+
+	case stateDockerRegistry:
+		return m.dockerRegistries.View()
+
+	case stateDockerPlatforms:
+		return m.dockerPlatformOptions.View()
+
+	case stateEnvironment:
+		if m.environmentError != "" {
+			return m.environmentOption.View() + fmt.Sprintf("\nInvalid choice: %s", m.environmentError)
+		}
+		return m.environmentOption.View()
+
+	case stateEnvironmentName, stateEnvironmentReviewers, stateEnvironmentWaitTimer:
+		prompt := map[state]string{
+			stateEnvironmentName:      "Enter the name of the GitHub Environment:",
+			stateEnvironmentReviewers: "Required reviewers for " + m.environment.Name + " (comma separated users or org/team):",
+			stateEnvironmentWaitTimer: "Wait timer for " + m.environment.Name + " in minutes:",
+		}[m.state]
+		errorLine := ""
+		if m.environmentError != "" {
+			errorLine = fmt.Sprintf("Invalid value: %s\n\n", m.environmentError)
+		}
+		return fmt.Sprintf("%s\n\n%s\n\n%s(Press Enter to continue)", prompt, m.environmentInput.View(), errorLine)
+
+	case stateEnvironmentBranchPolicy:
+		return m.branchPolicyOption.View()
+
+	case stateAnotherAccount:
+		return m.anotherAccountOption.View()
+
+	case stateSecretNaming:
+		return m.secretNamingOption.View()
+
+	case stateGitRemote:
+		return m.gitRemoteOption.View()
 
 	case stateGitHubUsername:
-		return fmt.Sprintf("Enter your GitHub username:\n\n%s\n\n(Press Enter to continue)", m.githubUsernameInput.View())
+		detected := ""
+		if m.gitRemote != nil {
+			detected = fmt.Sprintf("Detected %s from git remote %s.\n\n", m.gitRemote.URL, m.gitRemote.Name)
+			if m.gitRemoteWarning != "" {
+				detected += "Warning: " + m.gitRemoteWarning + ".\n\n"
+			}
+		}
+		return fmt.Sprintf("%sEnter the repository owner (user or organization):\n\n%s\n\n(Press Enter to continue)", detected, m.githubUsernameInput.View())
 
 	case stateGitHubRepoName:
 		return fmt.Sprintf("Enter your GitHub repository name:\n\n%s\n\n(Press Enter to continue)", m.githubRepoNameInput.View())
 
 	case stateGitHubToken:
-		return fmt.Sprintf("Enter your GitHub Personal Access Token (with 'repo' scope):\n\n%s\n\n(Press Enter to continue)", m.githubTokenInput.View())
+		return fmt.Sprintf("No token for this GitHub host found in the environment, the gh CLI config or git credential helpers.\nEnter your GitHub Personal Access Token (with 'repo' scope):\n\n%s\n\n(Press Enter to continue, Ctrl+R to show or hide the token)", m.githubTokenInput.View())
+
+	case stateSecretPreview:
+		return m.secretPreviewView()
 
 	case stateComplete:
+		if m.githubTokenSource != "" {
+			return fmt.Sprintf("Using the GitHub token from %s.\n\nWorkflow setup completed! Press Enter or Ctrl+C to exit.", m.githubTokenSource)
+		}
 		return "Workflow setup completed! Press Enter or Ctrl+C to exit."
 
+	case stateUploading:
+		return fmt.Sprintf("Uploading secrets to %s/%s...", m.githubUsername, m.githubRepoName)
+
+	case stateUploadReport:
+		report := strings.Join(m.uploadMessages, "\n") + "\n\n" + uploadTables(m.uploads)
+		if m.uploadFailed {
+			report += "Setup is incomplete: the workflow reads secrets or variables that were not stored.\n\n"
+			if overwritten := uploadedNames(m.uploads, secretReport.Overwritten); len(overwritten) > 0 {
+				report += fmt.Sprintf("Overwritten, previous values cannot be restored: %s\n\n", strings.Join(overwritten, ", "))
+			}
+		}
+		switch {
+		case m.rollingBack:
+			return secretValues.Redact(report) + "Deleting the secrets created by this run..."
+		case m.rollbackUploads != nil:
+			report += "Rollback:\n" + uploadTables(m.rollbackUploads)
+			for _, upload := range m.rollbackUploads {
+				if len(upload.report.Failed()) > 0 {
+					report += "Some secrets could not be deleted, remove them from the repository settings.\n\n"
+					break
+				}
+			}
+		case m.canRollback():
+			created := uploadedNames(m.uploads, secretReport.Created)
+			return secretValues.Redact(report) + fmt.Sprintf("Press d to delete the %d secret(s) created by this run (%s), or Enter to keep them and exit.",
+				len(created), strings.Join(created, ", "))
+		}
+		return secretValues.Redact(report) + "Press Enter to exit."
+
 	default:
 		return "An unexpected error occurred."
 	}
 }
+
+// uploadTables renders the report of each upload, headed by its store
+func uploadTables(uploads []secretUpload) string {
+	var b strings.Builder
+	for _, upload := range uploads {
+		if len(upload.report) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s:\n%s\n", upload.store, upload.report.Table())
+	}
+	return b.String()
+}
+
+// secretPreviewView lists the final secret and variable names, flagging
+// secrets that already exist and would be overwritten
+func (m model) secretPreviewView() string {
+	var b strings.Builder
+	if m.githubTokenSource != "" {
+		fmt.Fprintf(&b, "Using the GitHub token from %s.\n\n", m.githubTokenSource)
+	}
+	for _, preview := range m.previews {
+		if len(preview.secrets) > 0 {
+			fmt.Fprintf(&b, "Secrets to upload to %s:\n", preview.target)
+			for _, name := range preview.secrets {
+				note := ""
+				if preview.existing[strings.ToUpper(name)] {
+					note = "  (exists, will be overwritten)"
+				}
+				fmt.Fprintf(&b, "  %s%s\n", name, note)
+			}
+			b.WriteString("\n")
+		}
+		if len(preview.variables) > 0 {
+			fmt.Fprintf(&b, "Variables for %s:\n", preview.target)
+			for _, name := range preview.variables {
+				fmt.Fprintf(&b, "  %s\n", name)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	switch {
+	case m.existingSecretsErr != nil:
+		fmt.Fprintf(&b, "Could not check for existing secrets: %s\n\n", shortError(m.existingSecretsErr))
+	case !m.existingChecked:
+		b.WriteString("Checking for existing secrets...\n\n")
+	default:
+		if colliding := m.collidingSecrets(); colliding > 0 {
+			fmt.Fprintf(&b, "%d secret(s) already exist and will be overwritten. Press q to quit without changes.\n\n", colliding)
+		}
+	}
+	b.WriteString("(Press Enter to generate the workflow and upload)")
+	return secretValues.Redact(b.String())
+}
@@ -0,0 +1,168 @@
+package githubactions
+
+import "fmt"
+
+// awsProvider configures aws-actions/configure-aws-credentials
+type awsProvider struct{}
+
+var (
+	awsAccessKeyIDField = CredentialField{
+		Key:         "AWS_ACCESS_KEY_ID",
+		Label:       "AWS Access Key ID",
+		Placeholder: "Enter AWS Access Key ID",
+		CharLimit:   128,
+		Rotate:      true,
+		Validate:    matches(`^(AKIA|ASIA)[A-Z0-9]{16}$`, "an access key ID such as AKIA..."),
+	}
+	awsSecretAccessKeyField = CredentialField{
+		Key:         "AWS_SECRET_ACCESS_KEY",
+		Label:       "AWS Secret Access Key",
+		Placeholder: "Enter AWS Secret Access Key",
+		CharLimit:   128,
+		Sensitive:   true,
+		Rotate:      true,
+		Validate:    matches(`^[A-Za-z0-9/+=]{40}$`, "a 40 character secret access key"),
+	}
+	awsRoleARNField = CredentialField{
+		Key:         "AWS_ROLE_ARN",
+		Label:       "the ARN of the IAM role GitHub Actions should assume",
+		Placeholder: "arn:aws:iam::123456789012:role/github-actions",
+		CharLimit:   2048,
+		Validate:    matches(`^arn:aws[a-z-]*:iam::\d{12}:role/.+$`, "an IAM role ARN such as arn:aws:iam::123456789012:role/name"),
+	}
+	awsRegionField = CredentialField{
+		Key:         "AWS_REGION",
+		Label:       "AWS Region",
+		Placeholder: "Enter AWS Region",
+		CharLimit:   64,
+		Variable:    true,
+		Validate:    matches(`^[a-z]{2}(-gov|-iso[a-z]*)?-[a-z]+-\d+$`, "a region such as us-east-1"),
+	}
+)
+
+func (awsProvider) Name() string { return "AWS" }
+
+func (awsProvider) SupportsOIDC() bool { return true }
+
+func (awsProvider) Fields(oidc bool) []CredentialField {
+	if oidc {
+		return []CredentialField{awsRoleARNField, awsRegionField}
+	}
+	return []CredentialField{awsAccessKeyIDField, awsSecretAccessKeyField, awsRegionField}
+}
+
+func (p awsProvider) Secrets(cfg CloudConfig) map[string]string {
+	return fieldSecrets(p.Fields(cfg.OIDC), cfg)
+}
+
+func (p awsProvider) Variables(cfg CloudConfig) map[string]string {
+	return fieldVariables(p.Fields(cfg.OIDC), cfg)
+}
+
+func (p awsProvider) RotatedSecrets(cfg CloudConfig) map[string]string {
+	return fieldSecrets(RotationFields(p), cfg)
+}
+
+func (awsProvider) Steps(cfg CloudConfig) string {
+	if cfg.OIDC {
+		return fmt.Sprintf(`
+- name: Configure AWS Credentials
+  uses: aws-actions/configure-aws-credentials
+  with:
+    role-to-assume: %s
+    aws-region: %s`,
+			cfg.Ref("AWS_ROLE_ARN"), cfg.Ref("AWS_REGION"))
+	}
+	return fmt.Sprintf(`
+- name: Configure AWS Credentials
+  uses: aws-actions/configure-aws-credentials
+  with:
+    aws-access-key-id: %s
+    aws-secret-access-key: %s
+    aws-region: %s`,
+		cfg.Ref("AWS_ACCESS_KEY_ID"), cfg.Ref("AWS_SECRET_ACCESS_KEY"), cfg.Ref("AWS_REGION"))
+}
+
+func (awsProvider) DeployTargets() []DeployTarget {
+	return []DeployTarget{
+		{
+			Name: "S3 sync + CloudFront invalidation",
+			Fields: []CredentialField{
+				settingField("S3_SOURCE_DIRECTORY", "directory built by the build job to upload", "dist"),
+				settingField("S3_BUCKET", "S3 bucket name", "my-bucket"),
+				settingField("CLOUDFRONT_DISTRIBUTION_ID", "CloudFront distribution ID", "E1234567890ABC"),
+			},
+			Artifact: "S3_SOURCE_DIRECTORY",
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Sync to S3
+  run: aws s3 sync %s s3://%s --delete
+- name: Invalidate CloudFront cache
+  run: aws cloudfront create-invalidation --distribution-id %s --paths "/*"`,
+					cfg.Values["S3_SOURCE_DIRECTORY"], cfg.Values["S3_BUCKET"], cfg.Values["CLOUDFRONT_DISTRIBUTION_ID"])
+			},
+		},
+		{
+			Name: "ECS task update",
+			Fields: []CredentialField{
+				settingField("ECS_TASK_DEFINITION", "path to the ECS task definition JSON", "task-definition.json"),
+				settingField("ECS_CONTAINER_NAME", "container name in the task definition", "app"),
+				settingField("ECS_IMAGE", "image to deploy", "123456789012.dkr.ecr.us-east-1.amazonaws.com/app:latest"),
+				settingField("ECS_CLUSTER", "ECS cluster name", "my-cluster"),
+				settingField("ECS_SERVICE", "ECS service name", "my-service"),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Render ECS task definition
+  id: task-def
+  uses: aws-actions/amazon-ecs-render-task-definition
+  with:
+    task-definition: %s
+    container-name: %s
+    image: %s
+- name: Deploy ECS task definition
+  uses: aws-actions/amazon-ecs-deploy-task-definition
+  with:
+    task-definition: ${{ steps.task-def.outputs.task-definition }}
+    cluster: %s
+    service: %s
+    wait-for-service-stability: true`,
+					cfg.Values["ECS_TASK_DEFINITION"], cfg.Values["ECS_CONTAINER_NAME"], cfg.Values["ECS_IMAGE"],
+					cfg.Values["ECS_CLUSTER"], cfg.Values["ECS_SERVICE"])
+			},
+		},
+		{
+			Name: "Lambda function update",
+			Fields: []CredentialField{
+				settingField("LAMBDA_FUNCTION_NAME", "Lambda function name", "my-function"),
+				settingField("LAMBDA_SOURCE_DIRECTORY", "directory to package as the function code", "."),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Package Lambda function
+  run: cd %s && zip -qr "$GITHUB_WORKSPACE/function.zip" .
+- name: Update Lambda function code
+  run: aws lambda update-function-code --function-name %s --zip-file fileb://function.zip`,
+					cfg.Values["LAMBDA_SOURCE_DIRECTORY"], cfg.Values["LAMBDA_FUNCTION_NAME"])
+			},
+		},
+		{
+			Name: "SAM deploy",
+			Fields: []CredentialField{
+				settingField("SAM_STACK_NAME", "CloudFormation stack name", "my-stack"),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Set up SAM CLI
+  uses: aws-actions/setup-sam
+  with:
+    use-installer: true
+- name: SAM build
+  run: sam build
+- name: SAM deploy
+  run: sam deploy --stack-name %s --resolve-s3 --capabilities CAPABILITY_IAM --no-confirm-changeset --no-fail-on-empty-changeset`,
+					cfg.Values["SAM_STACK_NAME"])
+			},
+		},
+	}
+}
@@ -0,0 +1,170 @@
+package githubactions
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// azureProvider configures azure/login
+type azureProvider struct{}
+
+var (
+	azureClientIDField = CredentialField{
+		Key:         "AZURE_CLIENT_ID",
+		Label:       "Azure Client ID",
+		Placeholder: "Enter Azure Client ID",
+		CharLimit:   128,
+		Rotate:      true,
+		Validate:    matches(guidPattern, "a GUID such as 00000000-0000-0000-0000-000000000000"),
+	}
+	azureClientSecretField = CredentialField{
+		Key:         "AZURE_CLIENT_SECRET",
+		Label:       "Azure Client Secret",
+		Placeholder: "Enter Azure Client Secret",
+		CharLimit:   128,
+		Sensitive:   true,
+		Rotate:      true,
+		Validate:    required,
+	}
+	azureTenantIDField = CredentialField{
+		Key:         "AZURE_TENANT_ID",
+		Label:       "Azure Tenant ID",
+		Placeholder: "Enter Azure Tenant ID",
+		CharLimit:   128,
+		Variable:    true,
+		Rotate:      true,
+		Validate:    matches(guidPattern, "a GUID such as 00000000-0000-0000-0000-000000000000"),
+	}
+	azureSubscriptionIDField = CredentialField{
+		Key:         "AZURE_SUBSCRIPTION_ID",
+		Label:       "Azure Subscription ID",
+		Placeholder: "Enter Azure Subscription ID",
+		CharLimit:   128,
+		Variable:    true,
+		Rotate:      true,
+		Validate:    matches(guidPattern, "a GUID such as 00000000-0000-0000-0000-000000000000"),
+	}
+)
+
+func (azureProvider) Name() string { return "Azure" }
+
+func (azureProvider) SupportsOIDC() bool { return true }
+
+func (azureProvider) Fields(oidc bool) []CredentialField {
+	// OIDC federates the app registration, so no client secret is needed
+	if oidc {
+		return []CredentialField{azureClientIDField, azureTenantIDField, azureSubscriptionIDField}
+	}
+	return []CredentialField{azureClientIDField, azureClientSecretField, azureTenantIDField, azureSubscriptionIDField}
+}
+
+// Secrets stores the OIDC identifiers individually. With a client secret,
+// azure/login expects them combined into the AZURE_CREDENTIALS JSON document.
+func (p azureProvider) Secrets(cfg CloudConfig) map[string]string {
+	if cfg.OIDC {
+		return fieldSecrets(p.Fields(cfg.OIDC), cfg)
+	}
+
+	creds, _ := json.Marshal(map[string]string{
+		"clientId":       cfg.Values["AZURE_CLIENT_ID"],
+		"clientSecret":   cfg.Values["AZURE_CLIENT_SECRET"],
+		"tenantId":       cfg.Values["AZURE_TENANT_ID"],
+		"subscriptionId": cfg.Values["AZURE_SUBSCRIPTION_ID"],
+	})
+	return map[string]string{
+		cfg.SecretName("AZURE_CREDENTIALS"): string(creds),
+	}
+}
+
+// Variables is only used with OIDC, since the client secret flow bundles
+// every identifier into AZURE_CREDENTIALS
+func (p azureProvider) Variables(cfg CloudConfig) map[string]string {
+	if !cfg.OIDC {
+		return nil
+	}
+	return fieldVariables(p.Fields(cfg.OIDC), cfg)
+}
+
+// RotatedSecrets rebuilds AZURE_CREDENTIALS, so every identifier it bundles
+// is asked for along with the new client secret
+func (p azureProvider) RotatedSecrets(cfg CloudConfig) map[string]string {
+	cfg.OIDC = false
+	return p.Secrets(cfg)
+}
+
+func (azureProvider) Steps(cfg CloudConfig) string {
+	if cfg.OIDC {
+		return fmt.Sprintf(`
+- name: Azure Login
+  uses: azure/login
+  with:
+    client-id: %s
+    tenant-id: %s
+    subscription-id: %s`,
+			cfg.Ref("AZURE_CLIENT_ID"), cfg.Ref("AZURE_TENANT_ID"), cfg.Ref("AZURE_SUBSCRIPTION_ID"))
+	}
+	return fmt.Sprintf(`
+- name: Azure Login
+  uses: azure/login
+  with:
+    creds: %s`,
+		cfg.Ref("AZURE_CREDENTIALS"))
+}
+
+func (azureProvider) DeployTargets() []DeployTarget {
+	return []DeployTarget{
+		{
+			Name: "Web App",
+			Fields: []CredentialField{
+				settingField("AZURE_WEBAPP_NAME", "Azure Web App name", "my-webapp"),
+				settingField("AZURE_WEBAPP_PACKAGE", "path to the package or folder to deploy", "."),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Deploy to Azure Web App
+  uses: azure/webapps-deploy
+  with:
+    app-name: %s
+    package: %s`,
+					cfg.Values["AZURE_WEBAPP_NAME"], cfg.Values["AZURE_WEBAPP_PACKAGE"])
+			},
+		},
+		{
+			Name: "Functions",
+			Fields: []CredentialField{
+				settingField("AZURE_FUNCTIONAPP_NAME", "Azure Function App name", "my-functions"),
+				settingField("AZURE_FUNCTIONAPP_PACKAGE", "path to the Functions project", "."),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Deploy to Azure Functions
+  uses: azure/functions-action
+  with:
+    app-name: %s
+    package: %s`,
+					cfg.Values["AZURE_FUNCTIONAPP_NAME"], cfg.Values["AZURE_FUNCTIONAPP_PACKAGE"])
+			},
+		},
+		{
+			Name: "AKS",
+			Fields: []CredentialField{
+				settingField("AKS_RESOURCE_GROUP", "AKS resource group", "my-resource-group"),
+				settingField("AKS_CLUSTER_NAME", "AKS cluster name", "my-cluster"),
+				settingField("AKS_MANIFESTS", "path to the Kubernetes manifests", "k8s/"),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Set AKS context
+  uses: azure/aks-set-context
+  with:
+    resource-group: %s
+    cluster-name: %s
+- name: Deploy to AKS
+  uses: azure/k8s-deploy
+  with:
+    manifests: %s`,
+					cfg.Values["AKS_RESOURCE_GROUP"], cfg.Values["AKS_CLUSTER_NAME"], cfg.Values["AKS_MANIFESTS"])
+			},
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package githubactions
+
+import "fmt"
+
+// DefaultBranchCondition limits a job to runs on the repository's default
+// branch. Scheduled runs always use the default branch but their event
+// payload carries no repository, so they are matched by event name.
+const DefaultBranchCondition = "github.ref_name == github.event.repository.default_branch || github.event_name == 'schedule'"
+
+// deployArtifact is the artifact name used to hand build output to the deploy job
+const deployArtifact = "deploy-output"
+
+// DeployTarget is a deployment a cloud provider can run once authenticated
+type DeployTarget struct {
+	Name   string
+	Fields []CredentialField // settings the deployment needs
+	// Artifact is the key of the field naming a directory produced by the
+	// build job. It is uploaded there and downloaded again in the deploy job.
+	Artifact string
+	Steps    func(cfg CloudConfig) string
+}
+
+// GetDeploySkeleton generates the steps of the deploy job: checkout, the
+// provider's authentication and the target's deployment
+func GetDeploySkeleton(provider CloudProvider, target DeployTarget, cfg CloudConfig) string {
+	action := `
+- name: Checkout code
+  uses: actions/checkout
+`
+	if target.Artifact != "" {
+		action += fmt.Sprintf(`
+- name: Download build output
+  uses: actions/download-artifact
+  with:
+    name: %s
+    path: %s
+`, deployArtifact, cfg.Values[target.Artifact])
+	}
+
+	action += provider.Steps(cfg) + "\n"
+	action += target.Steps(cfg) + "\n"
+
+	return UseCatalogVersions(action)
+}
+
+// GetArtifactSkeleton generates the build job step handing the target's
+// build output to the deploy job. It is empty when no artifact is needed.
+func GetArtifactSkeleton(target DeployTarget, cfg CloudConfig) string {
+	if target.Artifact == "" {
+		return ""
+	}
+	return UseCatalogVersions(fmt.Sprintf(`
+- name: Upload build output
+  uses: actions/upload-artifact
+  with:
+    name: %s
+    path: %s
+`, deployArtifact, cfg.Values[target.Artifact]))
+}
@@ -0,0 +1,167 @@
+package githubactions
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DockerPlatforms lists the build platforms offered for multi-arch images
+var DockerPlatforms = []string{
+	"linux/amd64",
+	"linux/arm64",
+	"linux/arm/v7",
+}
+
+// DockerRegistry is a container registry the docker job can push to
+type DockerRegistry struct {
+	Name string
+	// Provider is the cloud provider whose login the registry reuses. It is
+	// empty for registries that authenticate with GITHUB_TOKEN.
+	Provider string
+	Fields   []CredentialField // settings the registry needs
+	Login    func(cfg CloudConfig) string
+	Image    func(cfg CloudConfig) string // image name without a tag
+	// Permissions are the GITHUB_TOKEN permissions needed to push
+	Permissions map[string]string
+}
+
+// DockerRegistries lists every supported registry
+var DockerRegistries = []DockerRegistry{
+	{
+		Name: "GitHub Container Registry (GHCR)",
+		Login: func(cfg CloudConfig) string {
+			return `
+- name: Log in to GHCR
+  uses: docker/login-action
+  with:
+    registry: ghcr.io
+    username: ${{ github.actor }}
+    password: ${{ secrets.GITHUB_TOKEN }}`
+		},
+		Image: func(cfg CloudConfig) string {
+			return "ghcr.io/${{ github.repository }}"
+		},
+		Permissions: map[string]string{
+			"contents": "read",
+			"packages": "write",
+		},
+	},
+	{
+		Name:     "Amazon ECR",
+		Provider: "AWS",
+		Fields: []CredentialField{
+			settingField("ECR_REPOSITORY", "ECR repository name", "my-app"),
+		},
+		Login: func(cfg CloudConfig) string {
+			return `
+- name: Log in to Amazon ECR
+  id: ecr
+  uses: aws-actions/amazon-ecr-login`
+		},
+		Image: func(cfg CloudConfig) string {
+			return "${{ steps.ecr.outputs.registry }}/" + cfg.Values["ECR_REPOSITORY"]
+		},
+	},
+	{
+		Name:     "Google Artifact Registry (GAR)",
+		Provider: "GCP",
+		Fields: []CredentialField{
+			settingField("GAR_LOCATION", "Artifact Registry location", "us-central1"),
+			settingField("GAR_REPOSITORY", "Artifact Registry repository", "my-repo"),
+			settingField("GAR_IMAGE", "image name", "my-app"),
+		},
+		Login: func(cfg CloudConfig) string {
+			return fmt.Sprintf(`
+- name: Log in to Artifact Registry
+  run: gcloud auth configure-docker %s-docker.pkg.dev --quiet`,
+				cfg.Values["GAR_LOCATION"])
+		},
+		Image: func(cfg CloudConfig) string {
+			return fmt.Sprintf("%s-docker.pkg.dev/%s/%s/%s",
+				cfg.Values["GAR_LOCATION"], cfg.ValueRef("GCP_PROJECT_ID"), cfg.Values["GAR_REPOSITORY"], cfg.Values["GAR_IMAGE"])
+		},
+	},
+	{
+		Name:     "Azure Container Registry (ACR)",
+		Provider: "Azure",
+		Fields: []CredentialField{
+			settingField("ACR_NAME", "ACR registry name (without .azurecr.io)", "myregistry"),
+			settingField("ACR_IMAGE", "image name", "my-app"),
+		},
+		Login: func(cfg CloudConfig) string {
+			return fmt.Sprintf(`
+- name: Log in to Azure Container Registry
+  run: az acr login --name %s`,
+				cfg.Values["ACR_NAME"])
+		},
+		Image: func(cfg CloudConfig) string {
+			return fmt.Sprintf("%s.azurecr.io/%s", cfg.Values["ACR_NAME"], cfg.Values["ACR_IMAGE"])
+		},
+	},
+}
+
+// FindDockerfile looks for a Dockerfile in dir and returns its path relative
+// to dir, or an empty string when there is none
+func FindDockerfile(dir string) string {
+	for _, name := range []string{"Dockerfile", "Containerfile", filepath.Join("docker", "Dockerfile")} {
+		if exists, _ := pathExists(filepath.Join(dir, name)); exists {
+			return filepath.ToSlash(name)
+		}
+	}
+	return ""
+}
+
+// GetDockerSkeleton generates the steps of the docker job. provider is the
+// configured cloud provider, used to log in to cloud registries.
+func GetDockerSkeleton(registry DockerRegistry, provider CloudProvider, cfg CloudConfig, dockerfile string, platforms []string) string {
+	if len(platforms) == 0 {
+		platforms = []string{"linux/amd64"}
+	}
+
+	action := `
+- name: Checkout code
+  uses: actions/checkout
+`
+	// QEMU emulates the platforms the runner cannot build natively
+	if len(platforms) > 1 || platforms[0] != "linux/amd64" {
+		action += `
+- name: Set up QEMU
+  uses: docker/setup-qemu-action
+`
+	}
+	action += `
+- name: Set up Docker Buildx
+  uses: docker/setup-buildx-action
+`
+	if registry.Provider != "" && provider != nil {
+		action += provider.Steps(cfg) + "\n"
+	}
+	action += registry.Login(cfg) + "\n"
+
+	action += fmt.Sprintf(`
+- name: Extract image metadata
+  id: meta
+  uses: docker/metadata-action
+  with:
+    images: %s
+    tags: |
+      type=ref,event=branch
+      type=ref,event=pr
+      type=semver,pattern={{version}}
+      type=sha
+- name: Build and push image
+  uses: docker/build-push-action
+  with:
+    context: .
+    file: %s
+    platforms: %s
+    push: ${{ github.event_name != 'pull_request' }}
+    tags: ${{ steps.meta.outputs.tags }}
+    labels: ${{ steps.meta.outputs.labels }}
+    cache-from: type=gha
+    cache-to: type=gha,mode=max
+`, registry.Image(cfg), dockerfile, strings.Join(platforms, ","))
+
+	return UseCatalogVersions(action)
+}
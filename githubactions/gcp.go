@@ -0,0 +1,158 @@
+package githubactions
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// gcpProvider configures google-github-actions/auth followed by setup-gcloud
+type gcpProvider struct{}
+
+var (
+	gcpServiceAccountKeyField = CredentialField{
+		Key:         "GOOGLE_APPLICATION_CREDENTIALS_JSON",
+		Label:       "GCP Service Account Key (JSON)",
+		Placeholder: "Enter GCP Service Account Key (JSON)",
+		CharLimit:   5000,
+		Sensitive:   true,
+		Rotate:      true,
+		Validate:    validateServiceAccountKey,
+	}
+	gcpWorkloadIdentityProviderField = CredentialField{
+		Key:         "GCP_WORKLOAD_IDENTITY_PROVIDER",
+		Label:       "GCP Workload Identity Provider resource name",
+		Placeholder: "projects/123/locations/global/workloadIdentityPools/pool/providers/github",
+		CharLimit:   512,
+		Validate: matches(`^projects/\d+/locations/global/workloadIdentityPools/[^/]+/providers/[^/]+$`,
+			"projects/<number>/locations/global/workloadIdentityPools/<pool>/providers/<provider>"),
+	}
+	gcpServiceAccountField = CredentialField{
+		Key:         "GCP_SERVICE_ACCOUNT",
+		Label:       "GCP Service Account email to impersonate",
+		Placeholder: "Enter GCP Service Account email",
+		CharLimit:   256,
+		Validate:    matches(`^[^@\s]+@[^@\s]+\.gserviceaccount\.com$`, "a service account email ending in .gserviceaccount.com"),
+	}
+	gcpProjectIDField = CredentialField{
+		Key:         "GCP_PROJECT_ID",
+		Label:       "GCP Project ID",
+		Placeholder: "Enter GCP Project ID",
+		CharLimit:   128,
+		Variable:    true,
+		Inline:      true,
+		Validate:    matches(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`, "a project ID such as my-project-123"),
+	}
+)
+
+func (gcpProvider) Name() string { return "GCP" }
+
+func (gcpProvider) SupportsOIDC() bool { return true }
+
+func (gcpProvider) Fields(oidc bool) []CredentialField {
+	// OIDC uses Workload Identity Federation instead of a service account key
+	if oidc {
+		return []CredentialField{gcpWorkloadIdentityProviderField, gcpServiceAccountField, gcpProjectIDField}
+	}
+	return []CredentialField{gcpServiceAccountKeyField, gcpProjectIDField}
+}
+
+func (p gcpProvider) Secrets(cfg CloudConfig) map[string]string {
+	return fieldSecrets(p.Fields(cfg.OIDC), cfg)
+}
+
+func (p gcpProvider) Variables(cfg CloudConfig) map[string]string {
+	return fieldVariables(p.Fields(cfg.OIDC), cfg)
+}
+
+func (p gcpProvider) RotatedSecrets(cfg CloudConfig) map[string]string {
+	return fieldSecrets(RotationFields(p), cfg)
+}
+
+func (gcpProvider) Steps(cfg CloudConfig) string {
+	auth := fmt.Sprintf(`
+    credentials_json: %s`, cfg.Ref("GOOGLE_APPLICATION_CREDENTIALS_JSON"))
+	if cfg.OIDC {
+		auth = fmt.Sprintf(`
+    workload_identity_provider: %s
+    service_account: %s`,
+			cfg.Ref("GCP_WORKLOAD_IDENTITY_PROVIDER"), cfg.Ref("GCP_SERVICE_ACCOUNT"))
+	}
+	return fmt.Sprintf(`
+- name: Authenticate to Google Cloud
+  uses: google-github-actions/auth
+  with:%s
+    project_id: %s
+- name: Set up Cloud SDK
+  uses: google-github-actions/setup-gcloud`,
+		auth, cfg.ValueRef("GCP_PROJECT_ID"))
+}
+
+// validateServiceAccountKey checks that the value is a service account JSON key
+func validateServiceAccountKey(value string) error {
+	var key struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal([]byte(value), &key); err != nil {
+		return fmt.Errorf("expected the service account key JSON: %v", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" {
+		return fmt.Errorf("expected a key with \"type\": \"service_account\"")
+	}
+	return nil
+}
+
+func (gcpProvider) DeployTargets() []DeployTarget {
+	return []DeployTarget{
+		{
+			Name: "Cloud Run",
+			Fields: []CredentialField{
+				settingField("CLOUD_RUN_SERVICE", "Cloud Run service name", "my-service"),
+				settingField("CLOUD_RUN_REGION", "Cloud Run region", "us-central1"),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Deploy to Cloud Run
+  uses: google-github-actions/deploy-cloudrun
+  with:
+    service: %s
+    region: %s
+    source: ./`,
+					cfg.Values["CLOUD_RUN_SERVICE"], cfg.Values["CLOUD_RUN_REGION"])
+			},
+		},
+		{
+			Name: "App Engine",
+			Fields: []CredentialField{
+				settingField("APP_ENGINE_DELIVERABLES", "App Engine deployment descriptor", "app.yaml"),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Deploy to App Engine
+  uses: google-github-actions/deploy-appengine
+  with:
+    deliverables: %s`,
+					cfg.Values["APP_ENGINE_DELIVERABLES"])
+			},
+		},
+		{
+			Name: "GKE",
+			Fields: []CredentialField{
+				settingField("GKE_CLUSTER_NAME", "GKE cluster name", "my-cluster"),
+				settingField("GKE_LOCATION", "GKE cluster location", "us-central1"),
+				settingField("GKE_MANIFESTS", "path to the Kubernetes manifests", "k8s/"),
+			},
+			Steps: func(cfg CloudConfig) string {
+				return fmt.Sprintf(`
+- name: Get GKE credentials
+  uses: google-github-actions/get-gke-credentials
+  with:
+    cluster_name: %s
+    location: %s
+- name: Deploy to GKE
+  run: kubectl apply -f %s`,
+					cfg.Values["GKE_CLUSTER_NAME"], cfg.Values["GKE_LOCATION"], cfg.Values["GKE_MANIFESTS"])
+			},
+		},
+	}
+}
@@ -0,0 +1,169 @@
+package githubactions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CredentialField describes a single value a cloud provider needs from the user
+type CredentialField struct {
+	Key         string // secret name suffix, e.g. "AWS_ACCESS_KEY_ID"
+	Label       string // prompt shown by the wizard
+	Placeholder string
+	CharLimit   int
+	Sensitive   bool               // mask the value while it is typed
+	Setting     bool               // written into the workflow instead of stored as a secret
+	Variable    bool               // stored as an Actions variable by default, see CloudConfig.Variables
+	Inline      bool               // written into the workflow unless stored as a variable, see CloudConfig.ValueRef
+	Rotate      bool               // asked for again by `secrets rotate`, see CloudProvider.RotatedSecrets
+	Validate    func(string) error // optional, nil accepts any value
+}
+
+// CloudConfig holds the answers collected for a cloud provider
+type CloudConfig struct {
+	Prefix string            // secret name prefix, usually the sanitized workflow name
+	Suffix string            // secret name suffix, such as an environment name
+	OIDC   bool              // use keyless authentication instead of long-lived keys
+	Values map[string]string // collected values keyed by CredentialField.Key
+	// Variables holds the field keys stored as Actions variables rather than
+	// secrets. Variables are readable in logs and through the API.
+	Variables map[string]bool
+}
+
+// SecretName returns the repository secret name used for a field key,
+// normalized to GitHub's naming rules
+func (c CloudConfig) SecretName(key string) string {
+	if c.Prefix != "" {
+		key = c.Prefix + "_" + key
+	}
+	if c.Suffix != "" {
+		key += "_" + c.Suffix
+	}
+	return SanitizeSecretName(key)
+}
+
+// Ref returns the expression reading a field from the vars or secrets context
+func (c CloudConfig) Ref(key string) string {
+	if c.Variables[key] {
+		return "${{ vars." + c.SecretName(key) + " }}"
+	}
+	return "${{ secrets." + c.SecretName(key) + " }}"
+}
+
+// ValueRef returns the variable reference for a field stored as a variable,
+// and otherwise the collected value itself
+func (c CloudConfig) ValueRef(key string) string {
+	if c.Variables[key] {
+		return c.Ref(key)
+	}
+	return c.Values[key]
+}
+
+// CloudProvider is a platform whose credentials workflo can configure. Adding
+// a provider only needs an implementation registered in CloudProviders; the
+// wizard renders its prompts from Fields.
+type CloudProvider interface {
+	// Name is shown in the wizard's provider list
+	Name() string
+	// SupportsOIDC reports whether keyless authentication is available
+	SupportsOIDC() bool
+	// Fields lists the values to collect for the chosen authentication mode
+	Fields(oidc bool) []CredentialField
+	// Secrets maps the collected values to the repository secrets to store
+	Secrets(cfg CloudConfig) map[string]string
+	// Variables maps the collected values to the Actions variables to store
+	Variables(cfg CloudConfig) map[string]string
+	// Steps returns the workflow steps the provider contributes as YAML
+	Steps(cfg CloudConfig) string
+	// DeployTargets lists the deployments that can follow authentication
+	DeployTargets() []DeployTarget
+	// RotatedSecrets maps the fields marked Rotate to the long-lived access
+	// key secrets `workflo secrets rotate` replaces
+	RotatedSecrets(cfg CloudConfig) map[string]string
+}
+
+// CloudProviders lists every supported provider in the order the wizard shows them
+var CloudProviders = []CloudProvider{
+	awsProvider{},
+	azureProvider{},
+	gcpProvider{},
+	cloudflareWorkersProvider,
+	cloudflarePagesProvider,
+	flyProvider,
+	digitalOceanProvider,
+	herokuProvider,
+	vercelProvider,
+	netlifyProvider,
+}
+
+// LookupCloudProvider finds a registered provider by name
+func LookupCloudProvider(name string) (CloudProvider, bool) {
+	for _, provider := range CloudProviders {
+		if provider.Name() == name {
+			return provider, true
+		}
+	}
+	return nil, false
+}
+
+// fieldSecrets stores every collected field, except settings and variables,
+// under its own secret name. Most providers use this as their Secrets
+// implementation.
+func fieldSecrets(fields []CredentialField, cfg CloudConfig) map[string]string {
+	secrets := make(map[string]string)
+	for _, field := range fields {
+		if field.Setting || (cfg.Variables[field.Key] && !field.Sensitive) {
+			continue
+		}
+		secrets[cfg.SecretName(field.Key)] = cfg.Values[field.Key]
+	}
+	return secrets
+}
+
+// RotationFields returns the fields of a provider's long-lived credentials
+// that `workflo secrets rotate` asks for
+func RotationFields(provider CloudProvider) []CredentialField {
+	var fields []CredentialField
+	for _, field := range provider.Fields(false) {
+		if field.Rotate {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// fieldVariables is the counterpart of fieldSecrets, storing the fields
+// chosen as variables. Sensitive fields are never stored as variables.
+func fieldVariables(fields []CredentialField, cfg CloudConfig) map[string]string {
+	variables := make(map[string]string)
+	for _, field := range fields {
+		if field.Setting || field.Sensitive || !cfg.Variables[field.Key] {
+			continue
+		}
+		variables[cfg.SecretName(field.Key)] = cfg.Values[field.Key]
+	}
+	return variables
+}
+
+// required rejects empty values
+func required(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("a value is required")
+	}
+	return nil
+}
+
+// matches returns a validator accepting values that match pattern
+func matches(pattern, description string) func(string) error {
+	re := regexp.MustCompile(pattern)
+	return func(value string) error {
+		if !re.MatchString(strings.TrimSpace(value)) {
+			return fmt.Errorf("expected %s", description)
+		}
+		return nil
+	}
+}
+
+// guidPattern matches Azure client, tenant and subscription IDs
+const guidPattern = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
@@ -0,0 +1,55 @@
+package githubactions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	secretNamePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	invalidSecretChars  = regexp.MustCompile(`[^A-Z0-9_]+`)
+	reservedSecretStart = "GITHUB_"
+)
+
+// SanitizeSecretName normalizes name to GitHub's secret and variable naming
+// rules: upper-case letters, digits and underscores, not starting with a
+// digit or the reserved GITHUB_ prefix. Valid upper-case names are unchanged.
+func SanitizeSecretName(name string) string {
+	name = invalidSecretChars.ReplaceAllString(strings.ToUpper(strings.TrimSpace(name)), "_")
+	if name == "" {
+		return ""
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	if strings.HasPrefix(name, reservedSecretStart) {
+		name = "GH_" + strings.TrimPrefix(name, reservedSecretStart)
+	}
+	return name
+}
+
+// SanitizeSecretPrefix sanitizes free text, such as a workflow name, for use
+// as a secret name prefix
+func SanitizeSecretPrefix(prefix string) string {
+	prefix = strings.Trim(invalidSecretChars.ReplaceAllString(strings.ToUpper(prefix), "_"), "_")
+	if prefix == "GITHUB" {
+		return "GH"
+	}
+	return SanitizeSecretName(prefix)
+}
+
+// ValidateSecretName checks name against GitHub's naming rules, suggesting
+// the sanitized name when it is invalid
+func ValidateSecretName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("secret names cannot be empty")
+	case !secretNamePattern.MatchString(name):
+		return fmt.Errorf("invalid secret name %q: only letters, digits and underscores are allowed and the name cannot start with a digit (try %s)",
+			name, SanitizeSecretName(name))
+	case strings.HasPrefix(strings.ToUpper(name), reservedSecretStart):
+		return fmt.Errorf("invalid secret name %q: names starting with GITHUB_ are reserved (try %s)", name, SanitizeSecretName(name))
+	}
+	return nil
+}
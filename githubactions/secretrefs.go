@@ -0,0 +1,117 @@
+package githubactions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SecretRef is a secret read by a workflow through the secrets context
+type SecretRef struct {
+	Name        string // upper-cased, as secret names are case-insensitive
+	Job         string // job reading the secret, empty for workflow-level env
+	Environment string // environment the job runs in, if any
+}
+
+var (
+	// expressionPattern matches a ${{ }} expression
+	expressionPattern = regexp.MustCompile(`\$\{\{(.*?)\}\}`)
+	// secretsContextPattern matches secrets.NAME and secrets['NAME']
+	secretsContextPattern = regexp.MustCompile(`\bsecrets\s*(?:\.\s*([A-Za-z_][A-Za-z0-9_]*)|\[\s*['"]([^'"]+)['"]\s*\])`)
+)
+
+// builtinSecrets are provided by GitHub rather than configured
+var builtinSecrets = map[string]bool{"GITHUB_TOKEN": true}
+
+// FindSecretRefs returns the secrets a workflow reads, once per job. Jobs
+// are listed in name order; references in the workflow-level env come first.
+func FindSecretRefs(content []byte) ([]SecretRef, error) {
+	var doc struct {
+		Env  interface{}            `yaml:"env"`
+		Jobs map[string]interface{} `yaml:"jobs"`
+	}
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("error parsing workflow: %v", err)
+	}
+
+	var refs []SecretRef
+	for _, name := range secretNames(doc.Env) {
+		refs = append(refs, SecretRef{Name: name})
+	}
+
+	jobs := make([]string, 0, len(doc.Jobs))
+	for job := range doc.Jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Strings(jobs)
+	for _, job := range jobs {
+		env := jobEnvironment(doc.Jobs[job])
+		for _, name := range secretNames(doc.Jobs[job]) {
+			refs = append(refs, SecretRef{Name: name, Job: job, Environment: env})
+		}
+	}
+	return refs, nil
+}
+
+// jobEnvironment reads a job's environment, given as a name or as a map
+// with a name
+func jobEnvironment(job interface{}) string {
+	fields, ok := job.(map[interface{}]interface{})
+	if !ok {
+		return ""
+	}
+	switch env := fields["environment"].(type) {
+	case string:
+		return env
+	case map[interface{}]interface{}:
+		name, _ := env["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// secretNames collects the secrets referenced anywhere in a YAML value.
+// `if:` conditions may omit the ${{ }} wrapper, other strings may not.
+func secretNames(value interface{}) []string {
+	seen := make(map[string]bool)
+	var walk func(key string, value interface{})
+	walk = func(key string, value interface{}) {
+		switch v := value.(type) {
+		case string:
+			expressions := []string{v}
+			if key != "if" {
+				expressions = nil
+				for _, match := range expressionPattern.FindAllStringSubmatch(v, -1) {
+					expressions = append(expressions, match[1])
+				}
+			}
+			for _, expression := range expressions {
+				for _, match := range secretsContextPattern.FindAllStringSubmatch(expression, -1) {
+					name := strings.ToUpper(match[1] + match[2])
+					if !builtinSecrets[name] {
+						seen[name] = true
+					}
+				}
+			}
+		case map[interface{}]interface{}:
+			for k, child := range v {
+				walk(fmt.Sprint(k), child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(key, child)
+			}
+		}
+	}
+	walk("", value)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
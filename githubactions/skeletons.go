@@ -1,7 +1,5 @@
 package githubactions
 
-import "fmt"
-
 // Basic reusable steps for common GitHub Actions workflows
 var BasicSteps = map[string]string{
 	//	"checkout": `
@@ -9,11 +7,11 @@ var BasicSteps = map[string]string{
 	//     uses: actions/checkout@v2`,
 }
 
-// Language-specific setup steps
+// Language-specific setup steps. Action versions are filled in from ActionCatalog.
 var LanguageSkeletons = map[string]string{
 	"Go": `
 	- name: Set up Go
-  uses: actions/setup-go@v2
+  uses: actions/setup-go
   with:
     go-version: '^1.15'
 - run: go build -v ./...
@@ -21,7 +19,7 @@ var LanguageSkeletons = map[string]string{
 
 	"Python": `
 	- name: Set up Python
- uses: actions/setup-python@v2
+  uses: actions/setup-python
   with:
     python-version: '3.x'
 - run: pip install -r requirements.txt
@@ -29,39 +27,26 @@ var LanguageSkeletons = map[string]string{
 
 	"Node.js": `
 	- name: Set up Node.js
- uses: actions/setup-node@v2
+  uses: actions/setup-node
   with:
     node-version: '16'
 - run: npm install
 - run: npm test`,
 }
 
-// Cloud provider-specific setup steps
-var CloudProviderSkeletons = map[string]string{
-	"AWS": `
-- name: Configure AWS Credentials
-  uses: aws-actions/configure-aws-credentials@v1
-  with:
-    aws-access-key-id: ${{ secrets.%[1]s_AWS_ACCESS_KEY_ID }}
-    aws-secret-access-key: ${{ secrets.%[1]s_AWS_SECRET_ACCESS_KEY }}
-    aws-region: ${{ secrets.%[1]s_AWS_REGION }}`,
-
-	"Azure": `
-- name: Azure Login
-  uses: azure/login@v1
-  with:
-    creds: ${{ secrets.%[1]s_AZURE_CREDENTIALS }}`,
-
-	"GCP": `
-- name: Authenticate to Google Cloud
-  uses: google-github-actions/setup-gcloud@v1
-  with:
-    service_account_key: ${{ secrets.%[1]s_GOOGLE_APPLICATION_CREDENTIALS_JSON }}
-    project_id: %[2]s`,
+// OIDCPermissions returns the workflow permissions needed to request an OIDC
+// token. Setting any permission revokes the rest, so contents: read is kept
+// for checkout.
+func OIDCPermissions() map[string]string {
+	return map[string]string{
+		"id-token": "write",
+		"contents": "read",
+	}
 }
 
-// GetSkeleton generates the steps based on selected language and cloud provider
-func GetSkeleton(language, cloudProvider, workflowNameUpper string, cloudParam string) string {
+// GetSkeleton generates the steps based on selected language and cloud provider.
+// provider may be nil when no cloud credentials are configured.
+func GetSkeleton(language string, provider CloudProvider, cfg CloudConfig) string {
 	action := ""
 
 	// Add language-specific setup if available
@@ -70,15 +55,9 @@ func GetSkeleton(language, cloudProvider, workflowNameUpper string, cloudParam s
 	}
 
 	// Add cloud provider-specific setup if available
-	if cloudSteps, ok := CloudProviderSkeletons[cloudProvider]; ok {
-		switch cloudProvider {
-		case "AWS", "GCP":
-			cloudSteps = fmt.Sprintf(cloudSteps, workflowNameUpper, cloudParam)
-		case "Azure":
-			cloudSteps = fmt.Sprintf(cloudSteps, workflowNameUpper)
-		}
-		action += cloudSteps + "\n"
+	if provider != nil {
+		action += provider.Steps(cfg) + "\n"
 	}
 
-	return action
+	return UseCatalogVersions(action)
 }
@@ -0,0 +1,135 @@
+package githubactions
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// WorkflowsDir is where GitHub looks for workflow files
+const WorkflowsDir = ".github/workflows"
+
+// UsesRef is a single `uses:` reference found in a workflow file
+type UsesRef struct {
+	Line    int    // zero-based line index within the file
+	Action  string // action path, e.g. "actions/checkout"
+	Ref     string // tag, branch or commit SHA after the @
+	Comment string // trailing comment without the leading '#', if any
+}
+
+// String returns the reference in `owner/repo@ref` form
+func (r UsesRef) String() string {
+	if r.Ref == "" {
+		return r.Action
+	}
+	return r.Action + "@" + r.Ref
+}
+
+// usesPattern matches `uses:` lines, keeping everything needed to rewrite the
+// reference without touching indentation, quoting or trailing comments
+var usesPattern = regexp.MustCompile(`^(\s*(?:-\s+)?uses:\s*)(["']?)([^@\s"'#]+)(?:@([^\s"'#]+))?(["']?)(\s+#\s*(.*?))?\s*$`)
+
+// shaPattern matches a full-length commit SHA
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsCommitSHA reports whether a ref is a full commit SHA
+func IsCommitSHA(ref string) bool {
+	return shaPattern.MatchString(ref)
+}
+
+// FindUses returns every remote action referenced in the given workflow YAML.
+// Local actions (./path) and docker:// images are skipped.
+func FindUses(content string) []UsesRef {
+	var refs []UsesRef
+	for i, line := range strings.Split(content, "\n") {
+		match := usesPattern.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+		if match == nil {
+			continue
+		}
+		action := match[3]
+		if strings.HasPrefix(action, "./") || strings.HasPrefix(action, "docker://") {
+			continue
+		}
+		refs = append(refs, UsesRef{
+			Line:    i,
+			Action:  action,
+			Ref:     match[4],
+			Comment: match[7],
+		})
+	}
+	return refs
+}
+
+// ReplaceUses points the reference on ref.Line at newRef. An empty comment
+// keeps the existing trailing comment; everything else on the line is preserved.
+func ReplaceUses(content string, ref UsesRef, newRef, comment string) string {
+	lines := strings.Split(content, "\n")
+	if ref.Line < 0 || ref.Line >= len(lines) {
+		return content
+	}
+
+	line := lines[ref.Line]
+	carriageReturn := strings.HasSuffix(line, "\r")
+	match := usesPattern.FindStringSubmatch(strings.TrimSuffix(line, "\r"))
+	if match == nil {
+		return content
+	}
+
+	if comment == "" {
+		comment = match[7]
+	}
+	updated := match[1] + match[2] + match[3] + "@" + newRef + match[5]
+	if comment != "" {
+		updated += " # " + comment
+	}
+	if carriageReturn {
+		updated += "\r"
+	}
+
+	lines[ref.Line] = updated
+	return strings.Join(lines, "\n")
+}
+
+// StripUsesComment removes the trailing comment from the `uses:` line at the
+// given index, keeping everything else on the line
+func StripUsesComment(content string, line int) string {
+	lines := strings.Split(content, "\n")
+	if line < 0 || line >= len(lines) {
+		return content
+	}
+
+	carriageReturn := strings.HasSuffix(lines[line], "\r")
+	text := strings.TrimSuffix(lines[line], "\r")
+	match := usesPattern.FindStringSubmatchIndex(text)
+	// Group 6 is the comment with the whitespace before it
+	if match == nil || match[12] < 0 {
+		return content
+	}
+
+	lines[line] = text[:match[12]]
+	if carriageReturn {
+		lines[line] += "\r"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// WorkflowFiles lists the workflow files in the given directory
+func WorkflowFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
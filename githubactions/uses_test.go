@@ -0,0 +1,20 @@
+package githubactions
+
+import "testing"
+
+func TestStripUsesComment(t *testing.T) {
+	tests := []struct {
+		name, content, want string
+	}{
+		{"comment", "  - uses: actions/checkout@v4 # v3.1.0\n", "  - uses: actions/checkout@v4\n"},
+		{"trailing whitespace", "  - uses: actions/checkout@v4 # v3.1.0  \t\n", "  - uses: actions/checkout@v4\n"},
+		{"carriage return", "  - uses: actions/checkout@v4 # v3.1.0 \r\n", "  - uses: actions/checkout@v4\r\n"},
+		{"quoted", "  - uses: \"actions/checkout@v4\"  # v3\n", "  - uses: \"actions/checkout@v4\"\n"},
+		{"no comment", "  - uses: actions/checkout@v4\n", "  - uses: actions/checkout@v4\n"},
+	}
+	for _, tt := range tests {
+		if got := StripUsesComment(tt.content, 0); got != tt.want {
+			t.Errorf("%s: StripUsesComment(%q) = %q, want %q", tt.name, tt.content, got, tt.want)
+		}
+	}
+}
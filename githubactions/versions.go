@@ -0,0 +1,115 @@
+package githubactions
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ActionVersion describes the latest known release of a GitHub Action
+type ActionVersion struct {
+	Major   string // tag generated workflows reference, e.g. "v4"
+	Release string // full release tag used when pinning, e.g. "v4.2.2"
+}
+
+// ActionCatalog is an offline list of the current major version of every
+// action used by the skeletons. Generation references the major tag by default.
+var ActionCatalog = map[string]ActionVersion{
+	"actions/checkout":                              {Major: "v4", Release: "v4.2.2"},
+	"actions/setup-go":                              {Major: "v5", Release: "v5.1.0"},
+	"actions/setup-python":                          {Major: "v5", Release: "v5.3.0"},
+	"actions/setup-node":                            {Major: "v4", Release: "v4.1.0"},
+	"actions/setup-java":                            {Major: "v4", Release: "v4.5.0"},
+	"actions/upload-artifact":                       {Major: "v4", Release: "v4.4.3"},
+	"actions/download-artifact":                     {Major: "v4", Release: "v4.1.8"},
+	"aws-actions/configure-aws-credentials":         {Major: "v4", Release: "v4.0.2"},
+	"azure/login":                                   {Major: "v2", Release: "v2.2.0"},
+	"google-github-actions/auth":                    {Major: "v2", Release: "v2.1.7"},
+	"google-github-actions/setup-gcloud":            {Major: "v2", Release: "v2.1.2"},
+	"docker/setup-qemu-action":                      {Major: "v3", Release: "v3.2.0"},
+	"docker/setup-buildx-action":                    {Major: "v3", Release: "v3.7.1"},
+	"docker/login-action":                           {Major: "v3", Release: "v3.3.0"},
+	"docker/metadata-action":                        {Major: "v5", Release: "v5.5.1"},
+	"docker/build-push-action":                      {Major: "v6", Release: "v6.9.0"},
+	"aws-actions/amazon-ecr-login":                  {Major: "v2", Release: "v2.0.1"},
+	"cloudflare/wrangler-action":                    {Major: "v3", Release: "v3.13.0"},
+	"digitalocean/app_action":                       {Major: "v2", Release: "v2.0.0"},
+	"superfly/flyctl-actions":                       {Major: "1.5", Release: "1.5"},
+	"aws-actions/amazon-ecs-render-task-definition": {Major: "v1", Release: "v1.6.0"},
+	"aws-actions/amazon-ecs-deploy-task-definition": {Major: "v2", Release: "v2.1.1"},
+	"aws-actions/setup-sam":                         {Major: "v2", Release: "v2.1.0"},
+	"azure/webapps-deploy":                          {Major: "v3", Release: "v3.0.1"},
+	"azure/functions-action":                        {Major: "v1", Release: "v1.5.2"},
+	"azure/aks-set-context":                         {Major: "v4", Release: "v4.0.1"},
+	"azure/k8s-deploy":                              {Major: "v5", Release: "v5.0.1"},
+	"google-github-actions/deploy-cloudrun":         {Major: "v2", Release: "v2.7.2"},
+	"google-github-actions/deploy-appengine":        {Major: "v2", Release: "v2.1.4"},
+	"google-github-actions/get-gke-credentials":     {Major: "v2", Release: "v2.2.1"},
+}
+
+// ActionRef returns the action reference at its catalog major version,
+// e.g. "actions/checkout@v4". Unknown actions are returned unchanged.
+func ActionRef(action string) string {
+	if version, ok := LookupAction(action); ok {
+		return action + "@" + version.Major
+	}
+	return action
+}
+
+// LookupAction finds the catalog entry for an action. Sub-path actions such as
+// "github/codeql-action/init" are looked up by their owner/repo.
+func LookupAction(action string) (ActionVersion, bool) {
+	version, ok := ActionCatalog[ActionRepo(action)]
+	return version, ok
+}
+
+// ActionRepo trims an action path down to the owner/repo hosting it
+func ActionRepo(action string) string {
+	parts := strings.SplitN(action, "/", 3)
+	if len(parts) < 2 {
+		return action
+	}
+	return parts[0] + "/" + parts[1]
+}
+
+// UseCatalogVersions rewrites every catalog action in a skeleton so it
+// references the current major version
+func UseCatalogVersions(stepsYaml string) string {
+	for _, ref := range FindUses(stepsYaml) {
+		if version, ok := LookupAction(ref.Action); ok && ref.Ref != version.Major {
+			stepsYaml = ReplaceUses(stepsYaml, ref, version.Major, "")
+		}
+	}
+	return stepsYaml
+}
+
+// majorPattern extracts the major version from tags such as v2, v2.1 or 2.1.0
+var majorPattern = regexp.MustCompile(`^v?(\d+)(?:\.\d+)*$`)
+
+// IsVersionComment reports whether a trailing `uses:` comment names a
+// version, like "# v3.1.0" noting the release behind a major tag
+func IsVersionComment(comment string) bool {
+	return majorPattern.MatchString(strings.TrimSpace(comment))
+}
+
+// LatestMajor reports the catalog major version when a reference is behind it.
+// Branches, commit SHAs and actions missing from the catalog are never outdated.
+func LatestMajor(ref UsesRef) (string, bool) {
+	version, ok := LookupAction(ref.Action)
+	if !ok {
+		return "", false
+	}
+
+	current := majorPattern.FindStringSubmatch(ref.Ref)
+	latest := majorPattern.FindStringSubmatch(version.Major)
+	if current == nil || latest == nil {
+		return "", false
+	}
+
+	currentMajor, _ := strconv.Atoi(current[1])
+	latestMajor, _ := strconv.Atoi(latest[1])
+	if currentMajor >= latestMajor {
+		return "", false
+	}
+	return version.Major, true
+}
@@ -6,17 +6,22 @@ type Workflow struct {
 	Name        string                 `yaml:"name"`
 	Description *string                `yaml:"description,omitempty"`
 	On          map[string]interface{} `yaml:"on"`
+	Permissions map[string]string      `yaml:"permissions,omitempty"`
 	Jobs        map[string]Job         `yaml:"jobs"`
 }
 
 type Job struct {
-	RunsOn string            `yaml:"runs-on"`
-	Steps  []Step            `yaml:"steps"`
-	Env    map[string]string `yaml:"env,omitempty"`
+	Needs       []string          `yaml:"needs,omitempty"`
+	If          string            `yaml:"if,omitempty"`
+	Environment string            `yaml:"environment,omitempty"`
+	RunsOn      string            `yaml:"runs-on"`
+	Steps       []Step            `yaml:"steps"`
+	Env         map[string]string `yaml:"env,omitempty"`
 }
 
 type Step struct {
 	Name string            `yaml:"name,omitempty"`
+	ID   string            `yaml:"id,omitempty"`
 	Uses string            `yaml:"uses,omitempty"`
 	Run  string            `yaml:"run,omitempty"`
 	Env  map[string]string `yaml:"env,omitempty"`
@@ -36,3 +41,13 @@ func NewWorkflow(name string) *Workflow {
 func (wf *Workflow) AddJob(jobName string, job Job) {
 	wf.Jobs[jobName] = job
 }
+
+// SetPermissions grants the given GITHUB_TOKEN permissions to the workflow
+func (wf *Workflow) SetPermissions(permissions map[string]string) {
+	if wf.Permissions == nil {
+		wf.Permissions = make(map[string]string)
+	}
+	for scope, access := range permissions {
+		wf.Permissions[scope] = access
+	}
+}
@@ -43,7 +43,7 @@ func ParseSteps(stepsYaml string) []Step {
 
 // Generates YAML from a Workflow struct and writes it to a file
 func (wf *Workflow) GenerateYAML(filename string, overwrite bool) error {
-	dirPath := ".github/workflows"
+	dirPath := WorkflowsDir
 
 	// Check if the directory exists, create it if not
 	dirExists, err := pathExists(dirPath)
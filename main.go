@@ -10,6 +10,15 @@ import (
 )
 
 func main() {
+	// Subcommands such as `workflo pin` run without the interactive wizard
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	p := tea.NewProgram(cli.NewModel())
 	if err := p.Start(); err != nil {
 		fmt.Println("Error running program:", err)